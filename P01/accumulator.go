@@ -0,0 +1,83 @@
+package main
+
+import "math"
+
+// Accumulator computes streaming statistics in a single pass using
+// Welford's online algorithm. The zero value is ready to use.
+type Accumulator struct {
+	n    int
+	mean float64
+	m2   float64 // sum of squared deviations from the running mean
+	min  float64
+	max  float64
+}
+
+// Add folds one value into the running statistics.
+func (a *Accumulator) Add(x float64) {
+	a.n++
+	if a.n == 1 {
+		a.mean, a.m2, a.min, a.max = x, 0, x, x
+		return
+	}
+	d := x - a.mean
+	a.mean += d / float64(a.n)
+	a.m2 += d * (x - a.mean)
+	a.min = math.Min(a.min, x)
+	a.max = math.Max(a.max, x)
+}
+
+// Merge combines the statistics of other into a (Chan et al. parallel update).
+func (a *Accumulator) Merge(other *Accumulator) {
+	if other == nil || other.n == 0 {
+		return
+	}
+	if a.n == 0 {
+		*a = *other
+		return
+	}
+	n := a.n + other.n
+	d := other.mean - a.mean
+	a.m2 += other.m2 + d*d*float64(a.n)*float64(other.n)/float64(n)
+	a.mean += d * float64(other.n) / float64(n)
+	a.min = math.Min(a.min, other.min)
+	a.max = math.Max(a.max, other.max)
+	a.n = n
+}
+
+func (a *Accumulator) Count() int { return a.n }
+
+func (a *Accumulator) Mean() (float64, error) {
+	if a.n == 0 {
+		return 0, ErrEmptySlice
+	}
+	return a.mean, nil
+}
+
+func (a *Accumulator) Variance() (float64, error) {
+	if a.n == 0 {
+		return 0, ErrEmptySlice
+	}
+	return a.m2 / float64(a.n), nil
+}
+
+func (a *Accumulator) Std() (float64, error) {
+	v, err := a.Variance()
+	if err != nil {
+		return 0, err
+	}
+	return math.Sqrt(v), nil
+}
+
+func (a *Accumulator) Min() (float64, error) {
+	if a.n == 0 {
+		return 0, ErrEmptySlice
+	}
+	return a.min, nil
+}
+
+func (a *Accumulator) Max() (float64, error) {
+	if a.n == 0 {
+		return 0, ErrEmptySlice
+	}
+	return a.max, nil
+}
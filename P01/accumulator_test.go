@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func almostEqual(a, b, tol float64) bool {
+	return math.Abs(a-b) <= tol*math.Max(1, math.Max(math.Abs(a), math.Abs(b)))
+}
+
+func TestAccumulatorMatchesBatch(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []float64
+	}{
+		{"normal", []float64{1, 2, 3}},
+		{"single", []float64{5}},
+		{"even", []float64{1, 2, 3, 4}},
+		{"constant", []float64{2, 2, 2, 2}},
+		{"mixed", []float64{-3.5, 10, 0.25, 7, -1, 42}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var acc Accumulator
+			for _, v := range tt.in {
+				acc.Add(v)
+			}
+			if acc.Count() != len(tt.in) {
+				t.Fatalf("count got=%d, want=%d", acc.Count(), len(tt.in))
+			}
+
+			wantMean, _ := Mean(tt.in)
+			wantVar, _ := Variance(tt.in)
+			wantStd, _ := Std(tt.in)
+			gotMean, _ := acc.Mean()
+			gotVar, _ := acc.Variance()
+			gotStd, _ := acc.Std()
+			if !almostEqual(gotMean, wantMean, 1e-12) {
+				t.Fatalf("mean got=%v, want=%v", gotMean, wantMean)
+			}
+			if !almostEqual(gotVar, wantVar, 1e-12) {
+				t.Fatalf("variance got=%v, want=%v", gotVar, wantVar)
+			}
+			if !almostEqual(gotStd, wantStd, 1e-12) {
+				t.Fatalf("std got=%v, want=%v", gotStd, wantStd)
+			}
+
+			lo, hi := tt.in[0], tt.in[0]
+			for _, v := range tt.in {
+				lo, hi = math.Min(lo, v), math.Max(hi, v)
+			}
+			if got, _ := acc.Min(); got != lo {
+				t.Fatalf("min got=%v, want=%v", got, lo)
+			}
+			if got, _ := acc.Max(); got != hi {
+				t.Fatalf("max got=%v, want=%v", got, hi)
+			}
+		})
+	}
+}
+
+func TestAccumulatorMerge(t *testing.T) {
+	data := []float64{4, 8, 15, 16, 23, 42, -7, 0.5}
+	for split := 0; split <= len(data); split++ {
+		var left, right, whole Accumulator
+		for _, v := range data[:split] {
+			left.Add(v)
+		}
+		for _, v := range data[split:] {
+			right.Add(v)
+		}
+		for _, v := range data {
+			whole.Add(v)
+		}
+		left.Merge(&right)
+
+		if left.Count() != whole.Count() {
+			t.Fatalf("split=%d count got=%d, want=%d", split, left.Count(), whole.Count())
+		}
+		gm, _ := left.Mean()
+		wm, _ := whole.Mean()
+		gv, _ := left.Variance()
+		wv, _ := whole.Variance()
+		if !almostEqual(gm, wm, 1e-12) || !almostEqual(gv, wv, 1e-12) {
+			t.Fatalf("split=%d got mean=%v var=%v, want mean=%v var=%v", split, gm, gv, wm, wv)
+		}
+		if lo, _ := left.Min(); lo != -7 {
+			t.Fatalf("split=%d min got=%v", split, lo)
+		}
+		if hi, _ := left.Max(); hi != 42 {
+			t.Fatalf("split=%d max got=%v", split, hi)
+		}
+	}
+}
+
+func TestAccumulatorEmpty(t *testing.T) {
+	var acc Accumulator
+	getters := map[string]func() (float64, error){
+		"mean":     acc.Mean,
+		"variance": acc.Variance,
+		"std":      acc.Std,
+		"min":      acc.Min,
+		"max":      acc.Max,
+	}
+	for name, get := range getters {
+		if _, err := get(); !errors.Is(err, ErrEmptySlice) {
+			t.Fatalf("%s: err=%v, want ErrEmptySlice", name, err)
+		}
+	}
+}
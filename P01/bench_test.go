@@ -28,3 +28,109 @@ func BenchmarkMedian_1e3(b *testing.B) {
 		_, _ = Median(data)
 	}
 }
+
+func BenchmarkMedian_1e5(b *testing.B) {
+	data := benchSlice(100_000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = Median(data)
+	}
+}
+
+func BenchmarkMedian_1e6(b *testing.B) {
+	data := benchSlice(1_000_000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = Median(data)
+	}
+}
+
+// sort-based baseline for comparison with the selection-based Median
+func BenchmarkMedianSort_1e3(b *testing.B) {
+	data := benchSlice(1_000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = medianSort(data)
+	}
+}
+
+func BenchmarkMedianSort_1e5(b *testing.B) {
+	data := benchSlice(100_000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = medianSort(data)
+	}
+}
+
+func BenchmarkMedianSort_1e6(b *testing.B) {
+	data := benchSlice(1_000_000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = medianSort(data)
+	}
+}
+
+func BenchmarkPercentiles_1e5(b *testing.B) {
+	data := benchSlice(100_000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = Percentiles(data, 50, 90, 95, 99)
+	}
+}
+
+var benchSizes = []struct {
+	name string
+	n    int
+}{
+	{"1e3", 1_000},
+	{"1e4", 10_000},
+	{"1e5", 100_000},
+	{"1e6", 1_000_000},
+	{"1e7", 10_000_000},
+}
+
+// BenchmarkVariance_Serial and BenchmarkVariance_Parallel are meant to be read
+// side by side to find the size where parallel starts to win.
+func BenchmarkVariance_Serial(b *testing.B) {
+	for _, s := range benchSizes {
+		data := benchSlice(s.n)
+		b.Run(s.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, _ = Variance(data)
+			}
+		})
+	}
+}
+
+func BenchmarkVariance_Parallel(b *testing.B) {
+	for _, s := range benchSizes {
+		data := benchSlice(s.n)
+		b.Run(s.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, _ = ParallelVariance(data)
+			}
+		})
+	}
+}
+
+func BenchmarkSum_Serial(b *testing.B) {
+	for _, s := range benchSizes {
+		data := benchSlice(s.n)
+		b.Run(s.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, _ = Sum(data)
+			}
+		})
+	}
+}
+
+func BenchmarkSum_Parallel(b *testing.B) {
+	for _, s := range benchSizes {
+		data := benchSlice(s.n)
+		b.Run(s.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, _ = ParallelSum(data)
+			}
+		})
+	}
+}
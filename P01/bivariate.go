@@ -0,0 +1,114 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+// Regression is the result of an ordinary least-squares fit y = Slope*x + Intercept.
+type Regression struct {
+	Slope     float64
+	Intercept float64
+	R2        float64 // coefficient of determination
+}
+
+// Covariance of paired samples; it divides by n-ddof like Variance.
+func Covariance[T Number](xdata, ydata []T, opts ...Option) (float64, error) {
+	o := buildOptions(opts)
+	xs, ys, err := preparePair(floats(xdata), floats(ydata), o)
+	if err != nil {
+		return 0, err
+	}
+	if err := requireDOF(len(xs), o.ddof); err != nil {
+		return 0, err
+	}
+	sxy, _, _ := coMoments(xs, ys)
+	return sxy / float64(len(xs)-o.ddof), nil
+}
+
+// Correlation returns the Pearson correlation coefficient of paired samples.
+func Correlation[T Number](xdata, ydata []T, opts ...Option) (float64, error) {
+	xs, ys, err := preparePair(floats(xdata), floats(ydata), buildOptions(opts))
+	if err != nil {
+		return 0, err
+	}
+	return pearson(xs, ys)
+}
+
+// SpearmanCorrelation is the Pearson correlation of the ranks, so it measures
+// any monotonic relationship. Ties get their average rank.
+func SpearmanCorrelation[T Number](xdata, ydata []T, opts ...Option) (float64, error) {
+	xs, ys, err := preparePair(floats(xdata), floats(ydata), buildOptions(opts))
+	if err != nil {
+		return 0, err
+	}
+	return pearson(ranks(xs), ranks(ys))
+}
+
+// LinearRegression fits ys = Slope*xs + Intercept by least squares.
+// R2 is 1 when ys is constant, since the fit is then exact.
+func LinearRegression[T Number](xdata, ydata []T, opts ...Option) (Regression, error) {
+	xs, ys, err := preparePair(floats(xdata), floats(ydata), buildOptions(opts))
+	if err != nil {
+		return Regression{}, err
+	}
+	sxy, sxx, syy := coMoments(xs, ys)
+	if sxx == 0 {
+		return Regression{}, ErrZeroVariance
+	}
+	slope := sxy / sxx
+	r := Regression{
+		Slope:     slope,
+		Intercept: mean(ys) - slope*mean(xs),
+		R2:        1,
+	}
+	if syy > 0 {
+		r.R2 = math.Min(1, sxy*sxy/(sxx*syy))
+	}
+	return r, nil
+}
+
+func pearson(xs, ys []float64) (float64, error) {
+	sxy, sxx, syy := coMoments(xs, ys)
+	if sxx == 0 || syy == 0 {
+		return 0, ErrZeroVariance
+	}
+	r := sxy / math.Sqrt(sxx*syy)
+	return math.Max(-1, math.Min(1, r)), nil
+}
+
+// coMoments returns the sums of co-deviations and squared deviations from the means.
+func coMoments(xs, ys []float64) (sxy, sxx, syy float64) {
+	mx, my := mean(xs), mean(ys)
+	var axy, axx, ayy neumaier
+	for i := range xs {
+		dx, dy := xs[i]-mx, ys[i]-my
+		axy.add(dx * dy)
+		axx.add(dx * dx)
+		ayy.add(dy * dy)
+	}
+	return axy.value(), axx.value(), ayy.value()
+}
+
+// ranks returns the 1-based rank of every value, averaging the ranks of ties.
+func ranks(xs []float64) []float64 {
+	idx := make([]int, len(xs))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return xs[idx[a]] < xs[idx[b]] })
+
+	out := make([]float64, len(xs))
+	for i := 0; i < len(idx); {
+		j := i + 1
+		for j < len(idx) && xs[idx[j]] == xs[idx[i]] {
+			j++
+		}
+		avg := float64(i+j+1) / 2 // mean of ranks i+1..j
+		for k := i; k < j; k++ {
+			out[idx[k]] = avg
+		}
+		i = j
+	}
+	return out
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestBivariate(t *testing.T) {
+	xs := []float64{1, 2, 3, 4, 5}
+	ys := []float64{2, 4, 5, 4, 5}
+	tests := []struct {
+		name string
+		f    func(xs, ys []float64, opts ...Option) (float64, error)
+		xs   []float64
+		ys   []float64
+		want float64
+		err  error
+	}{
+		{"covariance", Covariance, xs, ys, 1.2, nil},
+		{"covariance ddof=1", func(xs, ys []float64, _ ...Option) (float64, error) {
+			return Covariance(xs, ys, WithDDOF(1))
+		}, xs, ys, 1.5, nil},
+		{"pearson", Correlation, xs, ys, 0.7745966692414834, nil},
+		{"pearson perfect negative", Correlation, xs, []float64{10, 8, 6, 4, 2}, -1, nil},
+		{"spearman", SpearmanCorrelation, xs, ys, 0.7378647873726218, nil},
+		{"spearman monotonic", SpearmanCorrelation, xs, []float64{1, 8, 27, 64, 125}, 1, nil},
+
+		{"length mismatch", Correlation, xs, ys[:4], 0, ErrLengthMismatch},
+		{"empty", Covariance, nil, nil, 0, ErrEmptySlice},
+		{"constant", Correlation, xs, []float64{3, 3, 3, 3, 3}, 0, ErrZeroVariance},
+		{"nan", Covariance, xs, []float64{1, 2, math.NaN(), 4, 5}, 0, ErrNonFinite},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.f(tt.xs, tt.ys)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err=%v, want %v", err, tt.err)
+			}
+			if tt.err == nil && !almostEqual(got, tt.want, 1e-12) {
+				t.Fatalf("got=%v, want=%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinearRegression(t *testing.T) {
+	tests := []struct {
+		name string
+		xs   []float64
+		ys   []float64
+		want Regression
+		err  error
+	}{
+		{"exact line", []float64{0, 1, 2, 3}, []float64{1, 3, 5, 7}, Regression{2, 1, 1}, nil},
+		{"noisy", []float64{1, 2, 3, 4, 5}, []float64{2, 4, 5, 4, 5}, Regression{0.6, 2.2, 0.6}, nil},
+		{"constant y", []float64{1, 2, 3}, []float64{4, 4, 4}, Regression{0, 4, 1}, nil},
+		{"constant x", []float64{2, 2, 2}, []float64{1, 2, 3}, Regression{}, ErrZeroVariance},
+		{"length mismatch", []float64{1, 2}, []float64{1}, Regression{}, ErrLengthMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LinearRegression(tt.xs, tt.ys)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err=%v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				return
+			}
+			if !almostEqual(got.Slope, tt.want.Slope, 1e-12) ||
+				!almostEqual(got.Intercept, tt.want.Intercept, 1e-12) ||
+				!almostEqual(got.R2, tt.want.R2, 1e-12) {
+				t.Fatalf("got=%+v, want=%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBivariateSkipPairs(t *testing.T) {
+	xs := []float64{1, 2, math.NaN(), 3, 4}
+	ys := []float64{2, 4, 100, math.Inf(1), 8}
+	// pairs 2 and 3 are dropped together, leaving y = 2x exactly
+	got, err := Correlation(xs, ys, WithNonFinite(SkipNonFinite))
+	if err != nil || !almostEqual(got, 1, 1e-12) {
+		t.Fatalf("got=%v err=%v, want 1", got, err)
+	}
+}
+
+func TestRanksTies(t *testing.T) {
+	got := ranks([]float64{10, 20, 10, 30, 20, 10})
+	want := []float64{2, 4.5, 2, 6, 4.5, 2}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got=%v, want=%v", got, want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import "math/rand"
+
+// Statistic is a one-sample statistic such as Mean[float64] or Median[float64].
+type Statistic func([]float64, ...Option) (float64, error)
+
+// ConfidenceInterval is a bootstrap estimate of where a statistic lies.
+type ConfidenceInterval struct {
+	Estimate  float64 // statistic of the original sample
+	Lower     float64
+	Upper     float64
+	Level     float64 // e.g. 0.95
+	StdErr    float64 // standard deviation of the bootstrap replicates
+	Resamples int
+}
+
+// BootstrapCI resamples data with replacement, evaluates stat on every
+// resample and returns the percentile interval at the given level. Passing
+// the same rng seed reproduces the interval exactly; a nil rng uses a source
+// seeded with 1.
+func BootstrapCI[T Number](data []T, stat Statistic, resamples int, level float64, rng *rand.Rand) (ConfidenceInterval, error) {
+	xs, err := prepare(floats(data), buildOptions(nil))
+	if err != nil {
+		return ConfidenceInterval{}, err
+	}
+	if resamples < 1 {
+		return ConfidenceInterval{}, ErrTooFewValues
+	}
+	if !(level > 0 && level < 1) {
+		return ConfidenceInterval{}, ErrInvalidLevel
+	}
+	if rng == nil {
+		rng = rand.New(rand.NewSource(1))
+	}
+
+	est, err := stat(xs)
+	if err != nil {
+		return ConfidenceInterval{}, err
+	}
+	reps := make([]float64, resamples)
+	sample := make([]float64, len(xs))
+	for r := range reps {
+		for i := range sample {
+			sample[i] = xs[rng.Intn(len(xs))]
+		}
+		if reps[r], err = stat(sample); err != nil {
+			return ConfidenceInterval{}, err
+		}
+	}
+
+	tail := (1 - level) / 2 * 100
+	bounds, err := PercentilesWith(reps, []float64{tail, 100 - tail}, WithNonFinite(PropagateNonFinite))
+	if err != nil {
+		return ConfidenceInterval{}, err
+	}
+	ci := ConfidenceInterval{
+		Estimate:  est,
+		Lower:     bounds[0],
+		Upper:     bounds[1],
+		Level:     level,
+		Resamples: resamples,
+	}
+	if resamples > 1 {
+		ci.StdErr, _ = SampleStd(reps, WithNonFinite(PropagateNonFinite))
+	}
+	return ci, nil
+}
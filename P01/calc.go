@@ -1,59 +1,101 @@
 package main
 
-import (
-	"math"
-	"sort"
-)
+import "math"
 
-func Sum(xs []float64) (float64, error) {
-	if err := requireNonEmpty(xs); err != nil {
-		return 0, err
+// Sum adds xs. Floats use compensated summation; integers (including
+// time.Duration) are added exactly and fail with ErrOverflow instead of
+// wrapping around.
+func Sum[T Number](data []T, opts ...Option) (T, error) {
+	if !isFloat[T]() {
+		if err := requireNonEmpty(data); err != nil {
+			return 0, err
+		}
+		return sumInts(data, 0)
 	}
-	var s float64
-	for _, v := range xs {
-		s += v
+	xs, err := prepare(floats(data), buildOptions(opts))
+	if err != nil {
+		return 0, err
 	}
-	return s, nil
+	return T(sum(xs)), nil
 }
 
-func Mean(xs []float64) (float64, error) {
-	if err := requireNonEmpty(xs); err != nil {
+func Mean[T Number](data []T, opts ...Option) (float64, error) {
+	xs, err := prepare(floats(data), buildOptions(opts))
+	if err != nil {
 		return 0, err
 	}
-	s, _ := Sum(xs)
-	return s / float64(len(xs)), nil
+	return mean(xs), nil
 }
 
-func Median(xs []float64) (float64, error) {
-	if err := requireNonEmpty(xs); err != nil {
+func Median[T Number](data []T, opts ...Option) (float64, error) {
+	o := buildOptions(opts)
+	xs, err := prepare(floats(data), o)
+	if err != nil {
 		return 0, err
 	}
-	cp := append([]float64(nil), xs...)
-	sort.Float64s(cp)
-	n := len(cp)
-	if n%2 == 1 { // odd
-		return cp[n/2], nil
+	if o.nonFinite == PropagateNonFinite && hasNaN(xs) {
+		return math.NaN(), nil
 	}
-	return (cp[n/2-1] + cp[n/2]) / 2, nil
+	return median(xs), nil
 }
 
-func Variance(xs []float64) (float64, error) {
-	if err := requireNonEmpty(xs); err != nil {
+func Variance[T Number](data []T, opts ...Option) (float64, error) {
+	o := buildOptions(opts)
+	xs, err := prepare(floats(data), o)
+	if err != nil {
 		return 0, err
 	}
-	m, _ := Mean(xs)
-	var acc float64
-	for _, v := range xs {
-		d := v - m
-		acc += d * d
+	if err := requireDOF(len(xs), o.ddof); err != nil {
+		return 0, err
 	}
-	return acc / float64(len(xs)), nil
+	return variance(xs, o.ddof), nil
 }
 
-func Std(xs []float64) (float64, error) {
-	v, err := Variance(xs)
+func Std[T Number](xs []T, opts ...Option) (float64, error) {
+	v, err := Variance(xs, opts...)
 	if err != nil {
 		return 0, err
 	}
 	return math.Sqrt(v), nil
 }
+
+// The lowercase helpers below expect input that already passed prepare.
+
+func sum(xs []float64) float64 {
+	var s neumaier
+	for _, v := range xs {
+		s.add(v)
+	}
+	return s.value()
+}
+
+func mean(xs []float64) float64 {
+	return sum(xs) / float64(len(xs))
+}
+
+func median(xs []float64) float64 {
+	cp := append([]float64(nil), xs...)
+	n := len(cp)
+	m := selectKth(cp, n/2) // O(n) instead of a full sort
+	if n%2 == 1 {           // odd
+		return m
+	}
+	// everything left of n/2 is <= m, so the lower middle is their maximum
+	lower := cp[0]
+	for _, v := range cp[1 : n/2] {
+		if v > lower {
+			lower = v
+		}
+	}
+	return (lower + m) / 2
+}
+
+func variance(xs []float64, ddof int) float64 {
+	m := mean(xs)
+	var acc neumaier
+	for _, v := range xs {
+		d := v - m
+		acc.add(d * d)
+	}
+	return acc.value() / float64(len(xs)-ddof)
+}
@@ -1,6 +1,9 @@
 package main
 
-import "testing"
+import (
+	"math"
+	"testing"
+)
 
 func TestSum(t *testing.T) {
 	tests := []struct {
@@ -47,3 +50,78 @@ func TestMeanMedianStd(t *testing.T) {
 		t.Fatalf("std got=%v", std)
 	}
 }
+
+func repeatWith(head float64, v float64, n int, tail ...float64) []float64 {
+	xs := make([]float64, 0, n+1+len(tail))
+	xs = append(xs, head)
+	for i := 0; i < n; i++ {
+		xs = append(xs, v)
+	}
+	return append(xs, tail...)
+}
+
+func TestSumCompensated(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []float64
+		want float64
+	}{
+		// a naive loop returns 1e16: every 1.0 is below half an ulp of 1e16
+		{"large then ones", repeatWith(1e16, 1, 10_000), 1e16 + 10_000},
+		{"cancellation", []float64{1, 1e100, 1, -1e100}, 2},
+		{"tenths", repeatWith(0.1, 0.1, 9_999), 1000},
+		{"ones then large negative", repeatWith(-1e16, 1, 1_000, 1e16), 1_000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Sum(tt.in)
+			if err != nil {
+				t.Fatalf("err=%v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("got=%v, want=%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSumInfinite(t *testing.T) {
+	if got, err := Sum([]float64{1e308, 1e308}); err != nil || !math.IsInf(got, 1) {
+		t.Fatalf("overflow: got=%v err=%v, want +Inf", got, err)
+	}
+	if got, err := ParallelSum([]float64{-1e308, -1e308, 1}); err != nil || !math.IsInf(got, -1) {
+		t.Fatalf("parallel overflow: got=%v err=%v, want -Inf", got, err)
+	}
+	propagate := WithNonFinite(PropagateNonFinite)
+	if got, err := Sum([]float64{1, math.Inf(1)}, propagate); err != nil || !math.IsInf(got, 1) {
+		t.Fatalf("sum with +Inf: got=%v err=%v, want +Inf", got, err)
+	}
+	if got, err := Mean([]float64{1, math.Inf(1), 2}, propagate); err != nil || !math.IsInf(got, 1) {
+		t.Fatalf("mean with +Inf: got=%v err=%v, want +Inf", got, err)
+	}
+	if got, _ := Sum([]float64{math.Inf(1), math.Inf(-1)}, propagate); !math.IsNaN(got) {
+		t.Fatalf("+Inf + -Inf: got=%v, want NaN", got)
+	}
+}
+
+func TestMeanVarianceCompensated(t *testing.T) {
+	// 1e16 followed by ones: the naive mean is 1e16/n, losing all the ones
+	xs := repeatWith(1e16, 1, 999)
+	n := float64(len(xs))
+	mean, _ := Mean(xs)
+	if want := (1e16 + 999) / n; mean != want {
+		t.Fatalf("mean got=%v, want=%v", mean, want)
+	}
+
+	// a large offset must not change the variance of the sample
+	base := []float64{4, 7, 13, 16}
+	shifted := make([]float64, len(base))
+	for i, v := range base {
+		shifted[i] = v + 1e9
+	}
+	vb, _ := Variance(base)
+	vs, _ := Variance(shifted)
+	if vb != 22.5 || vs != vb {
+		t.Fatalf("variance base=%v shifted=%v, want 22.5", vb, vs)
+	}
+}
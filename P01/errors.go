@@ -1,5 +1,38 @@
 package main
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
-var ErrEmptySlice = errors.New("input slice is empty")
+var (
+	ErrEmptySlice      = errors.New("input slice is empty")
+	ErrInvalidQuantile = errors.New("quantile must be within [0, 1]")
+	ErrCorruptSketch   = errors.New("corrupt quantile sketch")
+	ErrNonFinite       = errors.New("non-finite value")
+	ErrTooFewValues    = errors.New("not enough values for the requested degrees of freedom")
+	ErrZeroVariance    = errors.New("input has zero variance")
+	ErrLengthMismatch  = errors.New("input slices have different lengths")
+	ErrNegativeWeight  = errors.New("weights must not be negative")
+	ErrZeroWeight      = errors.New("total weight is zero")
+	ErrInvalidWindow   = errors.New("window size must be positive")
+	ErrInvalidBins     = errors.New("invalid histogram bins")
+	ErrOverflow        = errors.New("integer overflow")
+	ErrInvalidLevel    = errors.New("confidence level must be within (0, 1)")
+	ErrInvalidAlpha    = errors.New("significance level must be within (0, 1)")
+
+	ErrIncompatibleHistogram = errors.New("histograms have different bucket edges")
+)
+
+// NonFiniteError reports the first NaN or ±Inf rejected by a stats function.
+// It matches ErrNonFinite with errors.Is.
+type NonFiniteError struct {
+	Index int
+	Value float64
+}
+
+func (e *NonFiniteError) Error() string {
+	return fmt.Sprintf("%v at index %d: %v", ErrNonFinite, e.Index, e.Value)
+}
+
+func (e *NonFiniteError) Unwrap() error { return ErrNonFinite }
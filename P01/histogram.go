@@ -0,0 +1,236 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const maxAutoBins = 1000
+
+// BinRule picks the number of bins for AutoHistogram.
+type BinRule int
+
+const (
+	// Sturges uses ceil(log2 n)+1 bins; good for small, roughly normal samples.
+	Sturges BinRule = iota
+	// FreedmanDiaconis uses a bin width of 2*IQR/n^(1/3); robust to outliers.
+	FreedmanDiaconis
+)
+
+// Histogram counts values into buckets defined by ascending edges: bucket i
+// covers [edges[i], edges[i+1]) and the last bucket also includes the top
+// edge. Values outside the edges go to the underflow and overflow counters.
+type Histogram struct {
+	edges     []float64
+	counts    []uint64
+	underflow uint64
+	overflow  uint64
+	nan       uint64
+}
+
+// NewHistogram builds a histogram from explicit bucket edges.
+func NewHistogram(edges []float64) (*Histogram, error) {
+	if len(edges) < 2 {
+		return nil, ErrInvalidBins
+	}
+	for i, e := range edges {
+		if !isFinite(e) || (i > 0 && e <= edges[i-1]) {
+			return nil, ErrInvalidBins
+		}
+	}
+	return &Histogram{
+		edges:  append([]float64(nil), edges...),
+		counts: make([]uint64, len(edges)-1),
+	}, nil
+}
+
+// NewLinearHistogram splits [min, max] into bins buckets of equal width.
+func NewLinearHistogram(min, max float64, bins int) (*Histogram, error) {
+	if bins < 1 || !(min < max) {
+		return nil, ErrInvalidBins
+	}
+	edges := make([]float64, bins+1)
+	for i := range edges {
+		// interpolated term by term: max-min overflows for ranges beyond ±MaxFloat64/2
+		f := float64(i) / float64(bins)
+		edges[i] = min*(1-f) + max*f
+	}
+	edges[0], edges[bins] = min, max
+	return NewHistogram(edges)
+}
+
+// NewLogHistogram splits [min, max] into bins buckets whose edges grow
+// geometrically, which suits latency-like data spanning several magnitudes.
+func NewLogHistogram(min, max float64, bins int) (*Histogram, error) {
+	if bins < 1 || !(min > 0 && min < max) {
+		return nil, ErrInvalidBins
+	}
+	edges := make([]float64, bins+1)
+	ratio := math.Log(max / min)
+	for i := range edges {
+		edges[i] = min * math.Exp(ratio*float64(i)/float64(bins))
+	}
+	edges[0], edges[bins] = min, max
+	return NewHistogram(edges)
+}
+
+// AutoHistogram builds a linear histogram over the range of xs with the number
+// of bins chosen by rule, and adds every value.
+func AutoHistogram[T Number](data []T, rule BinRule, opts ...Option) (*Histogram, error) {
+	xs, err := prepare(floats(data), buildOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	lo, hi := xs[0], xs[0]
+	for _, v := range xs {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+	if lo == hi { // a single distinct value gets one unit-wide bucket
+		lo, hi = lo-0.5, hi+0.5
+		if lo == hi { // too large for ±0.5 to register
+			lo, hi = math.Nextafter(lo, math.Inf(-1)), math.Nextafter(hi, math.Inf(1))
+		}
+	}
+
+	bins := sturgesBins(len(xs))
+	if rule == FreedmanDiaconis {
+		qs, _ := Percentiles(xs, 25, 75)
+		if iqr := qs[1] - qs[0]; iqr > 0 {
+			width := 2 * iqr / math.Cbrt(float64(len(xs)))
+			// halved on both sides so hi-lo cannot overflow
+			bins = int(math.Min(math.Ceil((hi/2-lo/2)/(width/2)), maxAutoBins))
+		}
+	}
+	bins = max(1, min(bins, maxAutoBins))
+
+	// a range only a few ulps wide cannot hold many distinct edges; use fewer
+	// bins until the edges are strictly increasing
+	h, err := NewLinearHistogram(lo, hi, bins)
+	for err != nil && bins > 1 {
+		bins--
+		h, err = NewLinearHistogram(lo, hi, bins)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range xs {
+		h.Add(v)
+	}
+	return h, nil
+}
+
+func sturgesBins(n int) int {
+	return int(math.Ceil(math.Log2(float64(n)))) + 1
+}
+
+func (h *Histogram) Add(x float64) {
+	last := len(h.edges) - 1
+	switch {
+	case math.IsNaN(x):
+		h.nan++
+	case x < h.edges[0]:
+		h.underflow++
+	case x > h.edges[last]:
+		h.overflow++
+	case x == h.edges[last]:
+		h.counts[last-1]++
+	default:
+		// first edge strictly greater than x closes x's bucket
+		i := sort.Search(len(h.edges), func(i int) bool { return h.edges[i] > x })
+		h.counts[i-1]++
+	}
+}
+
+// Merge adds the counts of other, which must have identical edges.
+func (h *Histogram) Merge(other *Histogram) error {
+	if other == nil || len(other.edges) != len(h.edges) {
+		return ErrIncompatibleHistogram
+	}
+	for i, e := range other.edges {
+		if e != h.edges[i] {
+			return ErrIncompatibleHistogram
+		}
+	}
+	for i, c := range other.counts {
+		h.counts[i] += c
+	}
+	h.underflow += other.underflow
+	h.overflow += other.overflow
+	h.nan += other.nan
+	return nil
+}
+
+func (h *Histogram) Edges() []float64  { return append([]float64(nil), h.edges...) }
+func (h *Histogram) Counts() []uint64  { return append([]uint64(nil), h.counts...) }
+func (h *Histogram) Underflow() uint64 { return h.underflow }
+func (h *Histogram) Overflow() uint64  { return h.overflow }
+func (h *Histogram) NaN() uint64       { return h.nan }
+
+// Total counts every value added, including under/overflow and NaN.
+func (h *Histogram) Total() uint64 {
+	t := h.underflow + h.overflow + h.nan
+	for _, c := range h.counts {
+		t += c
+	}
+	return t
+}
+
+// Render draws one bar per bucket, scaled so the fullest bucket is width
+// characters long. Under/overflow lines are only printed when non-zero.
+func (h *Histogram) Render(w io.Writer, width int) error {
+	if width < 1 {
+		width = 1
+	}
+	labels := make([]string, len(h.counts))
+	labelWidth, countWidth := 0, 1
+	var peak uint64
+	for i, c := range h.counts {
+		closing := ")"
+		if i == len(h.counts)-1 {
+			closing = "]"
+		}
+		labels[i] = fmt.Sprintf("[%s, %s%s", fmtEdge(h.edges[i]), fmtEdge(h.edges[i+1]), closing)
+		labelWidth = max(labelWidth, len(labels[i]))
+		countWidth = max(countWidth, len(strconv.FormatUint(c, 10)))
+		peak = max(peak, c)
+	}
+
+	line := func(label string, c uint64, bar string) error {
+		_, err := fmt.Fprintf(w, "%-*s %*d %s\n", labelWidth, label, countWidth, c, bar)
+		return err
+	}
+	if h.underflow > 0 {
+		if err := line("< "+fmtEdge(h.edges[0]), h.underflow, ""); err != nil {
+			return err
+		}
+	}
+	for i, c := range h.counts {
+		n := 0
+		if peak > 0 {
+			n = int(math.Round(float64(c) / float64(peak) * float64(width)))
+		}
+		if err := line(labels[i], c, strings.Repeat("#", n)); err != nil {
+			return err
+		}
+	}
+	if h.overflow > 0 {
+		if err := line("> "+fmtEdge(h.edges[len(h.edges)-1]), h.overflow, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *Histogram) String() string {
+	var sb strings.Builder
+	_ = h.Render(&sb, 40)
+	return sb.String()
+}
+
+func fmtEdge(v float64) string {
+	return strconv.FormatFloat(v, 'g', 4, 64)
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHistogramBuckets(t *testing.T) {
+	h, err := NewLinearHistogram(0, 10, 5)
+	if err != nil {
+		t.Fatalf("err=%v", err)
+	}
+	for _, v := range []float64{-1, 0, 1.9, 2, 5, 9.99, 10, 10.01, math.NaN()} {
+		h.Add(v)
+	}
+	if got, want := h.Counts(), []uint64{2, 1, 1, 0, 2}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("counts got=%v, want=%v", got, want)
+	}
+	if h.Underflow() != 1 || h.Overflow() != 1 || h.NaN() != 1 || h.Total() != 9 {
+		t.Fatalf("under=%d over=%d nan=%d total=%d", h.Underflow(), h.Overflow(), h.NaN(), h.Total())
+	}
+}
+
+func TestHistogramConstructors(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func() (*Histogram, error)
+		edges []float64
+		err   error
+	}{
+		{"linear", func() (*Histogram, error) { return NewLinearHistogram(0, 1, 4) }, []float64{0, 0.25, 0.5, 0.75, 1}, nil},
+		{"log", func() (*Histogram, error) { return NewLogHistogram(1, 1000, 3) }, []float64{1, 10, 100, 1000}, nil},
+		{"explicit", func() (*Histogram, error) { return NewHistogram([]float64{-5, 0, 100}) }, []float64{-5, 0, 100}, nil},
+		{"one edge", func() (*Histogram, error) { return NewHistogram([]float64{1}) }, nil, ErrInvalidBins},
+		{"unsorted", func() (*Histogram, error) { return NewHistogram([]float64{0, 2, 1}) }, nil, ErrInvalidBins},
+		{"zero bins", func() (*Histogram, error) { return NewLinearHistogram(0, 1, 0) }, nil, ErrInvalidBins},
+		{"empty range", func() (*Histogram, error) { return NewLinearHistogram(1, 1, 3) }, nil, ErrInvalidBins},
+		{"log non-positive", func() (*Histogram, error) { return NewLogHistogram(0, 10, 3) }, nil, ErrInvalidBins},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := tt.build()
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err=%v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				return
+			}
+			got := h.Edges()
+			for i := range tt.edges {
+				if !almostEqual(got[i], tt.edges[i], 1e-12) {
+					t.Fatalf("edges got=%v, want=%v", got, tt.edges)
+				}
+			}
+		})
+	}
+}
+
+func TestAutoHistogram(t *testing.T) {
+	xs := sketchTestData(1000, 2)
+	tests := []struct {
+		name string
+		rule BinRule
+		bins int
+	}{
+		{"sturges", Sturges, 11}, // ceil(log2 1000)+1
+		{"freedman-diaconis", FreedmanDiaconis, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := AutoHistogram(xs, tt.rule)
+			if err != nil {
+				t.Fatalf("err=%v", err)
+			}
+			if tt.bins > 0 && len(h.Counts()) != tt.bins {
+				t.Fatalf("bins got=%d, want=%d", len(h.Counts()), tt.bins)
+			}
+			if h.Total() != uint64(len(xs)) || h.Underflow() != 0 || h.Overflow() != 0 {
+				t.Fatalf("total=%d under=%d over=%d", h.Total(), h.Underflow(), h.Overflow())
+			}
+		})
+	}
+
+	h, err := AutoHistogram([]float64{3, 3, 3}, FreedmanDiaconis)
+	if err != nil || len(h.Counts()) == 0 || h.Total() != 3 {
+		t.Fatalf("constant input: h=%v err=%v", h, err)
+	}
+	if _, err := AutoHistogram([]float64(nil), Sturges); !errors.Is(err, ErrEmptySlice) {
+		t.Fatalf("empty: err=%v", err)
+	}
+}
+
+func TestAutoHistogramExtremeRanges(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []float64
+	}{
+		{"ulp-wide range", []float64{1e16, 1e16 + 2, 1e16 + 4}},
+		{"full float range", []float64{-1e308, 1e308}},
+		{"near max", []float64{math.MaxFloat64, -math.MaxFloat64, 0, 1}},
+		{"single huge value", []float64{1e300, 1e300}},
+	}
+	for _, tt := range tests {
+		for _, rule := range []BinRule{Sturges, FreedmanDiaconis} {
+			h, err := AutoHistogram(tt.in, rule)
+			if err != nil {
+				t.Fatalf("%s rule=%d: err=%v", tt.name, rule, err)
+			}
+			if h.Total() != uint64(len(tt.in)) || h.Underflow() != 0 || h.Overflow() != 0 {
+				t.Fatalf("%s rule=%d: total=%d under=%d over=%d", tt.name, rule, h.Total(), h.Underflow(), h.Overflow())
+			}
+		}
+	}
+}
+
+func TestHistogramMerge(t *testing.T) {
+	a, _ := NewLinearHistogram(0, 4, 4)
+	b, _ := NewLinearHistogram(0, 4, 4)
+	a.Add(0.5)
+	a.Add(-1)
+	b.Add(0.7)
+	b.Add(3.5)
+	b.Add(9)
+	if err := a.Merge(b); err != nil {
+		t.Fatalf("err=%v", err)
+	}
+	if got, want := a.Counts(), []uint64{2, 0, 0, 1}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("counts got=%v, want=%v", got, want)
+	}
+	if a.Underflow() != 1 || a.Overflow() != 1 {
+		t.Fatalf("under=%d over=%d", a.Underflow(), a.Overflow())
+	}
+
+	if err := a.Merge(nil); !errors.Is(err, ErrIncompatibleHistogram) {
+		t.Fatalf("nil: err=%v, want ErrIncompatibleHistogram", err)
+	}
+	c, _ := NewLinearHistogram(0, 4, 2)
+	if err := a.Merge(c); !errors.Is(err, ErrIncompatibleHistogram) {
+		t.Fatalf("err=%v, want ErrIncompatibleHistogram", err)
+	}
+}
+
+func TestHistogramRender(t *testing.T) {
+	h, _ := NewLinearHistogram(0, 2, 2)
+	for _, v := range []float64{0.5, 1.5, 1.6, 5} {
+		h.Add(v)
+	}
+	want := "" +
+		"[0, 1) 1 #####\n" +
+		"[1, 2] 2 ##########\n" +
+		"> 2    1 \n"
+	var sb strings.Builder
+	if err := h.Render(&sb, 10); err != nil {
+		t.Fatalf("err=%v", err)
+	}
+	if sb.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", sb.String(), want)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import "math"
+
+// TTestResult is the outcome of a two-sided two-sample t-test.
+type TTestResult struct {
+	T        float64
+	DF       float64 // Welch–Satterthwaite degrees of freedom
+	PValue   float64
+	MeanDiff float64 // mean(a) - mean(b)
+}
+
+// MannWhitneyResult is the outcome of a two-sided Mann–Whitney U test.
+type MannWhitneyResult struct {
+	U      float64 // U statistic of sample a
+	Z      float64 // normal approximation, with tie and continuity correction
+	PValue float64
+}
+
+// WelchTTest tests whether a and b have the same mean without assuming equal
+// variances, e.g. to compare two benchmark runs. Each sample needs 2+ values.
+func WelchTTest[T Number](adata, bdata []T, opts ...Option) (TTestResult, error) {
+	o := buildOptions(opts)
+	a, err := prepare(floats(adata), o)
+	if err != nil {
+		return TTestResult{}, err
+	}
+	b, err := prepare(floats(bdata), o)
+	if err != nil {
+		return TTestResult{}, err
+	}
+	if len(a) < 2 || len(b) < 2 {
+		return TTestResult{}, ErrTooFewValues
+	}
+
+	na, nb := float64(len(a)), float64(len(b))
+	sa, sb := variance(a, 1)/na, variance(b, 1)/nb
+	if sa+sb == 0 {
+		return TTestResult{}, ErrZeroVariance
+	}
+	diff := mean(a) - mean(b)
+	t := diff / math.Sqrt(sa+sb)
+	df := (sa + sb) * (sa + sb) / (sa*sa/(na-1) + sb*sb/(nb-1))
+	return TTestResult{
+		T:        t,
+		DF:       df,
+		PValue:   2 * studentTCDF(-math.Abs(t), df),
+		MeanDiff: diff,
+	}, nil
+}
+
+// MannWhitneyU tests whether values from a tend to be larger or smaller than
+// values from b, without assuming normality. The p-value uses the normal
+// approximation, which is reasonable once both samples have about 8+ values.
+func MannWhitneyU[T Number](adata, bdata []T, opts ...Option) (MannWhitneyResult, error) {
+	o := buildOptions(opts)
+	a, err := prepare(floats(adata), o)
+	if err != nil {
+		return MannWhitneyResult{}, err
+	}
+	b, err := prepare(floats(bdata), o)
+	if err != nil {
+		return MannWhitneyResult{}, err
+	}
+
+	all := append(append(make([]float64, 0, len(a)+len(b)), a...), b...)
+	r := ranks(all)
+	var ra float64
+	for _, v := range r[:len(a)] {
+		ra += v
+	}
+	n1, n2 := float64(len(a)), float64(len(b))
+	n := n1 + n2
+	u := ra - n1*(n1+1)/2
+
+	// tie correction: sum of t^3-t over groups of equal values
+	counts := make(map[float64]float64, len(all))
+	for _, v := range all {
+		counts[v]++
+	}
+	var ties float64
+	for _, t := range counts {
+		ties += t*t*t - t
+	}
+	sigma := math.Sqrt(n1 * n2 / 12 * ((n + 1) - ties/(n*(n-1))))
+	if sigma == 0 {
+		return MannWhitneyResult{}, ErrZeroVariance
+	}
+
+	mu := n1 * n2 / 2
+	d := u - mu
+	switch { // continuity correction towards the mean
+	case d > 0:
+		d = math.Max(0, d-0.5)
+	case d < 0:
+		d = math.Min(0, d+0.5)
+	}
+	z := d / sigma
+	return MannWhitneyResult{
+		U:      u,
+		Z:      z,
+		PValue: 2 * normalCDF(-math.Abs(z)),
+	}, nil
+}
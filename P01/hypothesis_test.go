@@ -0,0 +1,127 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestWelchTTest(t *testing.T) {
+	// example 1 from the Wikipedia article on Welch's t-test
+	a := []float64{27.5, 21.0, 19.0, 23.6, 17.0, 17.9, 16.9, 20.1, 21.9, 22.6, 23.1, 19.6, 19.0, 21.7, 21.4}
+	b := []float64{27.1, 22.0, 20.8, 23.4, 23.4, 23.5, 25.8, 22.0, 24.8, 20.2, 21.9, 22.1, 22.9, 20.5, 24.4}
+	got, err := WelchTTest(a, b)
+	if err != nil {
+		t.Fatalf("err=%v", err)
+	}
+	if math.Abs(got.T-(-2.46)) > 0.01 || math.Abs(got.DF-24.9) > 0.1 || math.Abs(got.PValue-0.021) > 0.001 {
+		t.Fatalf("got=%+v, want t≈-2.46 df≈24.9 p≈0.021", got)
+	}
+	if !almostEqual(got.MeanDiff, mean(a)-mean(b), 1e-12) {
+		t.Fatalf("mean diff got=%v", got.MeanDiff)
+	}
+
+	same, _ := WelchTTest(a, a)
+	if same.T != 0 || !almostEqual(same.PValue, 1, 1e-12) {
+		t.Fatalf("identical samples got=%+v", same)
+	}
+}
+
+func TestMannWhitneyU(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []float64
+		u    float64
+		z    float64
+		p    float64
+	}{
+		{"separated", []float64{1, 2, 3, 4, 5}, []float64{6, 7, 8, 9, 10}, 0, -2.5067182457620487, 0.012185780355344818},
+		{"ties", []float64{1, 2, 2, 3, 5}, []float64{2, 4, 4, 6, 7, 8}, 5, -1.7545069317741127, 0.07934368319771508},
+		{"reversed", []float64{6, 7, 8, 9, 10}, []float64{1, 2, 3, 4, 5}, 25, 2.5067182457620487, 0.012185780355344818},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MannWhitneyU(tt.a, tt.b)
+			if err != nil {
+				t.Fatalf("err=%v", err)
+			}
+			if got.U != tt.u || !almostEqual(got.Z, tt.z, 1e-12) || !almostEqual(got.PValue, tt.p, 1e-9) {
+				t.Fatalf("got=%+v, want U=%v Z=%v p=%v", got, tt.u, tt.z, tt.p)
+			}
+		})
+	}
+}
+
+func TestHypothesisErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func() error
+		want error
+	}{
+		{"welch too few", func() error { _, err := WelchTTest([]float64{1}, []float64{1, 2}); return err }, ErrTooFewValues},
+		{"welch constant", func() error { _, err := WelchTTest([]float64{1, 1}, []float64{2, 2}); return err }, ErrZeroVariance},
+		{"welch nan", func() error { _, err := WelchTTest([]float64{1, math.NaN()}, []float64{2, 3}); return err }, ErrNonFinite},
+		{"mann-whitney empty", func() error { _, err := MannWhitneyU([]float64{}, []float64{2}); return err }, ErrEmptySlice},
+		{"mann-whitney all tied", func() error { _, err := MannWhitneyU([]float64{3, 3}, []float64{3}); return err }, ErrZeroVariance},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.run(); !errors.Is(err, tt.want) {
+				t.Fatalf("err=%v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestBootstrapCI(t *testing.T) {
+	xs := sketchTestData(400, 21) // mean 25, sd 8
+
+	ci, err := BootstrapCI(xs, Mean[float64], 2000, 0.95, rand.New(rand.NewSource(42)))
+	if err != nil {
+		t.Fatalf("err=%v", err)
+	}
+	m, _ := Mean(xs)
+	sd, _ := SampleStd(xs)
+	se := sd / math.Sqrt(float64(len(xs)))
+	if ci.Estimate != m || ci.Level != 0.95 || ci.Resamples != 2000 {
+		t.Fatalf("ci=%+v", ci)
+	}
+	if !(ci.Lower < m && m < ci.Upper) {
+		t.Fatalf("estimate %v outside [%v, %v]", m, ci.Lower, ci.Upper)
+	}
+	// the percentile interval of the mean should be close to the normal theory one
+	if math.Abs(ci.Lower-(m-1.96*se)) > 0.3*se || math.Abs(ci.Upper-(m+1.96*se)) > 0.3*se {
+		t.Fatalf("ci=[%v, %v], normal theory=[%v, %v]", ci.Lower, ci.Upper, m-1.96*se, m+1.96*se)
+	}
+	if math.Abs(ci.StdErr-se) > 0.15*se {
+		t.Fatalf("stderr got=%v, want≈%v", ci.StdErr, se)
+	}
+
+	again, _ := BootstrapCI(xs, Mean[float64], 2000, 0.95, rand.New(rand.NewSource(42)))
+	if again != ci {
+		t.Fatalf("same seed gave different results: %+v vs %+v", again, ci)
+	}
+
+	med, err := BootstrapCI(xs, Median[float64], 500, 0.9, nil)
+	if err != nil || !(med.Lower <= med.Estimate && med.Estimate <= med.Upper) {
+		t.Fatalf("median ci=%+v err=%v", med, err)
+	}
+}
+
+func TestBootstrapCIErrors(t *testing.T) {
+	xs := []float64{1, 2, 3}
+	if _, err := BootstrapCI(xs, Mean[float64], 0, 0.95, nil); !errors.Is(err, ErrTooFewValues) {
+		t.Fatalf("resamples=0: err=%v", err)
+	}
+	if _, err := BootstrapCI(xs, Mean[float64], 10, 1, nil); !errors.Is(err, ErrInvalidLevel) {
+		t.Fatalf("level=1: err=%v", err)
+	}
+	if _, err := BootstrapCI([]float64{}, Mean[float64], 10, 0.9, nil); !errors.Is(err, ErrEmptySlice) {
+		t.Fatalf("empty: err=%v", err)
+	}
+	// a statistic that fails on some resample surfaces its error
+	if _, err := BootstrapCI([]float64{1, 1, 1, 2}, Skewness[float64], 200, 0.9, nil); !errors.Is(err, ErrZeroVariance) {
+		t.Fatalf("skewness: err=%v", err)
+	}
+}
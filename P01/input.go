@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// inputFormat is how numbers are laid out in an input stream.
+type inputFormat string
+
+const (
+	formatAuto  inputFormat = "auto"
+	formatCSV   inputFormat = "csv"
+	formatLines inputFormat = "lines"
+	formatJSON  inputFormat = "json"
+)
+
+type inputConfig struct {
+	format inputFormat
+	column string // CSV column: 1-based index or header name
+	header bool   // CSV has a header row
+}
+
+// RowError is a malformed row, reported with its position instead of being dropped.
+type RowError struct {
+	Source string
+	Line   int
+	Err    error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("%s:%d: %v", e.Source, e.Line, e.Err)
+}
+
+func (e *RowError) Unwrap() error { return e.Err }
+
+// readValues parses all numbers in r. Malformed rows are returned as
+// RowErrors next to the values that did parse; err is only set when the
+// stream as a whole cannot be read.
+func readValues(r io.Reader, source string, cfg inputConfig) ([]float64, []*RowError, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch detectFormat(source, data, cfg.format) {
+	case formatCSV:
+		return readCSV(data, source, cfg)
+	case formatJSON:
+		return readJSON(data, source)
+	default:
+		return readLines(data, source)
+	}
+}
+
+func detectFormat(source string, data []byte, f inputFormat) inputFormat {
+	if f != formatAuto && f != "" {
+		return f
+	}
+	switch strings.ToLower(filepath.Ext(source)) {
+	case ".csv":
+		return formatCSV
+	case ".json":
+		return formatJSON
+	}
+	if t := bytes.TrimSpace(data); len(t) > 0 && t[0] == '[' {
+		return formatJSON
+	}
+	return formatLines
+}
+
+func parseValue(s string) (float64, error) {
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		var ne *strconv.NumError
+		if errors.As(err, &ne) {
+			err = ne.Err
+		}
+		return 0, fmt.Errorf("invalid number %q: %w", s, err)
+	}
+	if !isFinite(v) {
+		return 0, fmt.Errorf("%w: %v", ErrNonFinite, v)
+	}
+	return v, nil
+}
+
+// readLines reads one number per line; blank lines and # comments are ignored.
+func readLines(data []byte, source string) ([]float64, []*RowError, error) {
+	var (
+		values []float64
+		bad    []*RowError
+	)
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		s := strings.TrimSpace(sc.Text())
+		if s == "" || strings.HasPrefix(s, "#") {
+			continue
+		}
+		v, err := parseValue(s)
+		if err != nil {
+			bad = append(bad, &RowError{Source: source, Line: line, Err: err})
+			continue
+		}
+		values = append(values, v)
+	}
+	return values, bad, sc.Err()
+}
+
+func readCSV(data []byte, source string, cfg inputConfig) ([]float64, []*RowError, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	r.Comment = '#'
+
+	col, byIndex := 0, true
+	if n, err := strconv.Atoi(cfg.column); err == nil {
+		if n < 1 {
+			return nil, nil, fmt.Errorf("%s: column index must be >= 1, got %d", source, n)
+		}
+		col = n - 1
+	} else if cfg.column != "" {
+		byIndex = false
+	}
+	header := cfg.header || !byIndex
+
+	var (
+		values []float64
+		bad    []*RowError
+	)
+	for first := true; ; first = false {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var pe *csv.ParseError
+			if errors.As(err, &pe) {
+				bad = append(bad, &RowError{Source: source, Line: pe.Line, Err: pe.Err})
+				continue
+			}
+			return nil, nil, err
+		}
+		line, _ := r.FieldPos(0)
+		if first && header {
+			if !byIndex {
+				col = indexOf(rec, cfg.column)
+				if col < 0 {
+					return nil, nil, fmt.Errorf("%s: column %q not found in header %v", source, cfg.column, rec)
+				}
+			}
+			continue
+		}
+		if col >= len(rec) {
+			bad = append(bad, &RowError{Source: source, Line: line, Err: fmt.Errorf("row has %d fields, want column %d", len(rec), col+1)})
+			continue
+		}
+		v, err := parseValue(rec[col])
+		if err != nil {
+			bad = append(bad, &RowError{Source: source, Line: line, Err: err})
+			continue
+		}
+		values = append(values, v)
+	}
+	return values, bad, nil
+}
+
+func indexOf(rec []string, name string) int {
+	for i, f := range rec {
+		if strings.EqualFold(strings.TrimSpace(f), name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// readJSON reads a JSON array of numbers. Elements that are not numbers are
+// reported with their line; a syntax error aborts the whole source.
+func readJSON(data []byte, source string) ([]float64, []*RowError, error) {
+	lineAt := func(off int64) int { return bytes.Count(data[:off], []byte("\n")) + 1 }
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s:%d: %w", source, lineAt(dec.InputOffset()), err)
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '[' {
+		return nil, nil, fmt.Errorf("%s:%d: expected a JSON array", source, lineAt(dec.InputOffset()))
+	}
+
+	var (
+		values []float64
+		bad    []*RowError
+	)
+	for dec.More() {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, nil, fmt.Errorf("%s:%d: %w", source, lineAt(dec.InputOffset()), err)
+		}
+		// InputOffset is now just past the element; count back to where it began.
+		line := lineAt(dec.InputOffset() - int64(len(raw)))
+		var num json.Number
+		if err := json.Unmarshal(raw, &num); err != nil {
+			bad = append(bad, &RowError{Source: source, Line: line, Err: fmt.Errorf("not a number: %s", raw)})
+			continue
+		}
+		v, err := parseValue(num.String())
+		if err != nil {
+			bad = append(bad, &RowError{Source: source, Line: line, Err: err})
+			continue
+		}
+		values = append(values, v)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, nil, fmt.Errorf("%s:%d: %w", source, lineAt(dec.InputOffset()), err)
+	}
+	return values, bad, nil
+}
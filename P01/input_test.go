@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadValues(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		cfg      inputConfig
+		in       string
+		want     []float64
+		badLines []int
+	}{
+		{"lines", "<stdin>", inputConfig{format: formatAuto}, "1\n2.5\n\n# note\nabc\n4\n", []float64{1, 2.5, 4}, []int{5}},
+		{"lines nan", "<stdin>", inputConfig{format: formatLines}, "1\nNaN\n3\n", []float64{1, 3}, []int{2}},
+		{"csv index", "data.csv", inputConfig{format: formatAuto, column: "2"}, "a,1\nb,2\nc,x\nd\n", []float64{1, 2}, []int{3, 4}},
+		{"csv header name", "data.csv", inputConfig{format: formatAuto, column: "price"}, "sym,price\nBTC,100\nETH,oops\nADA,0.5\n", []float64{100, 0.5}, []int{3}},
+		{"csv header flag", "<stdin>", inputConfig{format: formatCSV, column: "1", header: true}, "v\n7\n8\n", []float64{7, 8}, nil},
+		{"json", "<stdin>", inputConfig{format: formatAuto}, "[\n  1,\n  2.5,\n  \"x\",\n  null,\n  4\n]\n", []float64{1, 2.5, 4}, []int{4, 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, bad, err := readValues(strings.NewReader(tt.in), tt.source, tt.cfg)
+			if err != nil {
+				t.Fatalf("err=%v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("values got=%v, want=%v", got, tt.want)
+			}
+			var lines []int
+			for _, e := range bad {
+				if e.Source != tt.source {
+					t.Fatalf("source got=%q, want=%q", e.Source, tt.source)
+				}
+				lines = append(lines, e.Line)
+			}
+			if !reflect.DeepEqual(lines, tt.badLines) {
+				t.Fatalf("bad lines got=%v, want=%v", lines, tt.badLines)
+			}
+		})
+	}
+}
+
+func TestReadValuesFatal(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  inputConfig
+		in   string
+	}{
+		{"json syntax", inputConfig{format: formatJSON}, "[1, 2,"},
+		{"json object", inputConfig{format: formatJSON}, `{"a": 1}`},
+		{"csv missing column", inputConfig{format: formatCSV, column: "price"}, "a,b\n1,2\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := readValues(strings.NewReader(tt.in), "in", tt.cfg); err == nil {
+				t.Fatalf("want error")
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	specs, err := parseStats("count,sum,mean,median,p50")
+	if err != nil {
+		t.Fatalf("parseStats err=%v", err)
+	}
+	cfg := cliConfig{input: inputConfig{format: formatAuto}, stats: specs, out: "json"}
+
+	var stdout, stderr bytes.Buffer
+	in := strings.NewReader("1\n2\nbad\n3\n4\n")
+	if err := run(nil, in, &stdout, &stderr, cfg); err != nil {
+		t.Fatalf("run err=%v", err)
+	}
+	if got, want := stdout.String(), `{"count":4,"sum":10,"mean":2.5,"median":2.5,"p50":2.5}`+"\n"; got != want {
+		t.Fatalf("stdout got=%q, want=%q", got, want)
+	}
+	if !strings.Contains(stderr.String(), "<stdin>:3:") {
+		t.Fatalf("stderr does not report line 3: %q", stderr.String())
+	}
+
+	cfg.strict = true
+	err = run(nil, strings.NewReader("1\nbad\n"), &stdout, &stderr, cfg)
+	if err == nil {
+		t.Fatalf("strict: want error")
+	}
+
+	cfg.strict = false
+	err = run(nil, strings.NewReader("bad\n"), &stdout, &stderr, cfg)
+	if !errors.Is(err, ErrEmptySlice) {
+		t.Fatalf("no values: err=%v, want ErrEmptySlice", err)
+	}
+}
+
+func TestRunJSONNonFinite(t *testing.T) {
+	specs, err := parseStats("count,sum,mean,std")
+	if err != nil {
+		t.Fatalf("parseStats err=%v", err)
+	}
+	cfg := cliConfig{input: inputConfig{format: formatAuto}, stats: specs, out: "json"}
+
+	var stdout, stderr bytes.Buffer
+	if err := run(nil, strings.NewReader("1e308\n1e308\n"), &stdout, &stderr, cfg); err != nil {
+		t.Fatalf("run err=%v", err)
+	}
+	var got map[string]*float64
+	if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, stdout.String())
+	}
+	if got["count"] == nil || *got["count"] != 2 || got["sum"] != nil || got["mean"] != nil {
+		t.Fatalf("got=%s, want sum and mean as null", stdout.String())
+	}
+}
+
+func TestParseStats(t *testing.T) {
+	if _, err := parseStats("mean,p101"); err == nil {
+		t.Fatalf("p101: want error")
+	}
+	if _, err := parseStats("mode"); err == nil {
+		t.Fatalf("mode: want error")
+	}
+	specs, err := parseStats(" Mean , p99.9 ")
+	if err != nil || len(specs) != 2 || specs[1].q != 99.9 {
+		t.Fatalf("specs=%v err=%v", specs, err)
+	}
+}
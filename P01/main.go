@@ -1,11 +1,125 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io"
+	"os"
 )
 
+type cliConfig struct {
+	input  inputConfig
+	stats  []statSpec
+	out    string
+	strict bool
+	hist   string // bin rule for an optional histogram: "", "sturges" or "fd"
+}
+
 func main() {
-	nums := []float64{1, 2, 3, 4, 5}
-	mean, _ := Mean(nums)
-	fmt.Println("Mean:", mean) // 3
+	var (
+		format = flag.String("format", "auto", "input format: auto, csv, lines or json")
+		column = flag.String("col", "1", "CSV column: 1-based index or header name")
+		header = flag.Bool("header", false, "CSV input has a header row (implied when -col is a name)")
+		stats  = flag.String("stats", defaultStats, "comma-separated stats: count,sum,mean,median,std,var,min,max,pNN")
+		out    = flag.String("out", "table", "output format: table, json or csv")
+		strict = flag.Bool("strict", false, "fail on malformed rows instead of skipping them")
+		hist   = flag.String("hist", "", "also draw a histogram with bins chosen by \"sturges\" or \"fd\" (table output only)")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] [file ...]\n\nReads numbers from the files (or stdin when none, or \"-\") and prints statistics.\n\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	specs, err := parseStats(*stats)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(2)
+	}
+	switch *out {
+	case "table", "json", "csv":
+	default:
+		fmt.Fprintf(os.Stderr, "error: unknown output format %q\n", *out)
+		os.Exit(2)
+	}
+	switch {
+	case *hist != "" && *hist != "sturges" && *hist != "fd":
+		fmt.Fprintf(os.Stderr, "error: unknown bin rule %q\n", *hist)
+		os.Exit(2)
+	case *hist != "" && *out != "table":
+		fmt.Fprintln(os.Stderr, "error: -hist requires -out=table")
+		os.Exit(2)
+	}
+	cfg := cliConfig{
+		input:  inputConfig{format: inputFormat(*format), column: *column, header: *header},
+		stats:  specs,
+		out:    *out,
+		strict: *strict,
+		hist:   *hist,
+	}
+	if err := run(flag.Args(), os.Stdin, os.Stdout, os.Stderr, cfg); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+// run reads every source, reports malformed rows on stderr and writes the
+// selected stats to stdout.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer, cfg cliConfig) error {
+	if len(args) == 0 {
+		args = []string{"-"}
+	}
+
+	var (
+		values []float64
+		bad    []*RowError
+	)
+	for _, name := range args {
+		src, r := name, stdin
+		if name == "-" {
+			src = "<stdin>"
+		} else {
+			f, err := os.Open(name)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			r = f
+		}
+		vs, rowErrs, err := readValues(r, src, cfg.input)
+		if err != nil {
+			return err
+		}
+		values = append(values, vs...)
+		bad = append(bad, rowErrs...)
+	}
+
+	for _, e := range bad {
+		fmt.Fprintln(stderr, "malformed row:", e)
+	}
+	if len(bad) > 0 && cfg.strict {
+		return fmt.Errorf("%d malformed rows", len(bad))
+	}
+
+	results, err := computeStats(values, cfg.stats)
+	if err != nil {
+		return err
+	}
+	if err := writeResults(stdout, cfg.out, results); err != nil {
+		return err
+	}
+	if cfg.hist == "" {
+		return nil
+	}
+
+	rule := Sturges
+	if cfg.hist == "fd" {
+		rule = FreedmanDiaconis
+	}
+	h, err := AutoHistogram(values, rule)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout)
+	return h.Render(stdout, 50)
 }
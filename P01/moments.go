@@ -0,0 +1,92 @@
+package main
+
+import "math"
+
+// SampleVariance is Variance with the n-1 (Bessel) divisor. opts is capped
+// before appending so the caller's backing array is never written to.
+func SampleVariance[T Number](xs []T, opts ...Option) (float64, error) {
+	return Variance(xs, append(opts[:len(opts):len(opts)], WithDDOF(1))...)
+}
+
+// SampleStd is the square root of SampleVariance.
+func SampleStd[T Number](xs []T, opts ...Option) (float64, error) {
+	return Std(xs, append(opts[:len(opts):len(opts)], WithDDOF(1))...)
+}
+
+// Skewness returns the Fisher-Pearson moment coefficient g1 = m3 / m2^1.5.
+func Skewness[T Number](data []T, opts ...Option) (float64, error) {
+	xs, err := prepare(floats(data), buildOptions(opts))
+	if err != nil {
+		return 0, err
+	}
+	m2, m3, _ := centralMoments(xs)
+	if m2 == 0 {
+		return 0, ErrZeroVariance
+	}
+	return m3 / math.Pow(m2, 1.5), nil
+}
+
+// Kurtosis returns the excess kurtosis g2 = m4 / m2^2 - 3 (0 for a normal distribution).
+func Kurtosis[T Number](data []T, opts ...Option) (float64, error) {
+	xs, err := prepare(floats(data), buildOptions(opts))
+	if err != nil {
+		return 0, err
+	}
+	m2, _, m4 := centralMoments(xs)
+	if m2 == 0 {
+		return 0, ErrZeroVariance
+	}
+	return m4/(m2*m2) - 3, nil
+}
+
+// MeanAbsDev returns the mean absolute deviation around the mean.
+func MeanAbsDev[T Number](data []T, opts ...Option) (float64, error) {
+	xs, err := prepare(floats(data), buildOptions(opts))
+	if err != nil {
+		return 0, err
+	}
+	m := mean(xs)
+	var acc neumaier
+	for _, v := range xs {
+		acc.add(math.Abs(v - m))
+	}
+	return acc.value() / float64(len(xs)), nil
+}
+
+// MedianAbsDev returns the (unscaled) median absolute deviation around the
+// median. Multiply by 1.4826 to estimate the standard deviation of normal data.
+func MedianAbsDev[T Number](data []T, opts ...Option) (float64, error) {
+	o := buildOptions(opts)
+	xs, err := prepare(floats(data), o)
+	if err != nil {
+		return 0, err
+	}
+	if o.nonFinite == PropagateNonFinite && hasNaN(xs) {
+		return math.NaN(), nil
+	}
+	return medianAbsDev(xs), nil
+}
+
+func medianAbsDev(xs []float64) float64 {
+	med := median(xs)
+	dev := make([]float64, len(xs))
+	for i, v := range xs {
+		dev[i] = math.Abs(v - med)
+	}
+	return median(dev)
+}
+
+// centralMoments returns the 2nd, 3rd and 4th population central moments.
+func centralMoments(xs []float64) (m2, m3, m4 float64) {
+	m := mean(xs)
+	var s2, s3, s4 neumaier
+	for _, v := range xs {
+		d := v - m
+		d2 := d * d
+		s2.add(d2)
+		s3.add(d2 * d)
+		s4.add(d2 * d2)
+	}
+	n := float64(len(xs))
+	return s2.value() / n, s3.value() / n, s4.value() / n
+}
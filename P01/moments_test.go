@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestMoments(t *testing.T) {
+	xs := []float64{2, 4, 4, 4, 5, 5, 7, 9}
+	tests := []struct {
+		name string
+		f    statFunc
+		in   []float64
+		want float64
+		err  error
+	}{
+		{"variance", Variance, xs, 4, nil},
+		{"variance ddof=1", func(xs []float64, opts ...Option) (float64, error) {
+			return Variance(xs, WithDDOF(1))
+		}, xs, 32.0 / 7, nil},
+		{"sample variance", SampleVariance, xs, 32.0 / 7, nil},
+		{"sample std", SampleStd, xs, math.Sqrt(32.0 / 7), nil},
+		{"skewness", Skewness, xs, 0.65625, nil},
+		{"kurtosis", Kurtosis, xs, -0.21875, nil},
+		{"mean abs dev", MeanAbsDev, xs, 1.5, nil},
+		{"median abs dev", MedianAbsDev, xs, 0.5, nil},
+		{"median abs dev odd", MedianAbsDev, []float64{1, 1, 2, 2, 4, 6, 9}, 1, nil},
+
+		{"sample variance single", SampleVariance, []float64{5}, 0, ErrTooFewValues},
+		{"skewness constant", Skewness, []float64{3, 3, 3}, 0, ErrZeroVariance},
+		{"kurtosis constant", Kurtosis, []float64{3, 3, 3}, 0, ErrZeroVariance},
+		{"skewness empty", Skewness, nil, 0, ErrEmptySlice},
+		{"kurtosis empty", Kurtosis, nil, 0, ErrEmptySlice},
+		{"mean abs dev empty", MeanAbsDev, nil, 0, ErrEmptySlice},
+		{"median abs dev empty", MedianAbsDev, nil, 0, ErrEmptySlice},
+		{"skewness nan", Skewness, []float64{1, math.NaN()}, 0, ErrNonFinite},
+		{"median abs dev inf", MedianAbsDev, []float64{math.Inf(1)}, 0, ErrNonFinite},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.f(tt.in)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err=%v, want %v", err, tt.err)
+			}
+			if tt.err == nil && !almostEqual(got, tt.want, 1e-12) {
+				t.Fatalf("got=%v, want=%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVarianceDDOF(t *testing.T) {
+	if _, err := Variance([]float64{1, 2}, WithDDOF(2)); !errors.Is(err, ErrTooFewValues) {
+		t.Fatalf("ddof=n: err=%v", err)
+	}
+	if _, err := Variance([]float64{1, 2}, WithDDOF(-1)); !errors.Is(err, ErrTooFewValues) {
+		t.Fatalf("ddof<0: err=%v", err)
+	}
+	// options compose: skip NaN first, then apply the n-1 divisor
+	got, err := SampleVariance([]float64{1, math.NaN(), 3}, WithNonFinite(SkipNonFinite))
+	if err != nil || got != 2 {
+		t.Fatalf("got=%v err=%v, want 2", got, err)
+	}
+}
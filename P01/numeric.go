@@ -0,0 +1,52 @@
+package main
+
+import "fmt"
+
+// Number is the constraint of the generic stats functions: any integer or
+// floating-point type, including named ones such as time.Duration. Results
+// other than Sum are float64 in the unit of the input (nanoseconds for
+// time.Duration); int64 values beyond 2^53 lose precision in that conversion.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// floats converts xs to []float64, returning it unchanged when it already is one.
+func floats[T Number](xs []T) []float64 {
+	if fs, ok := any(xs).([]float64); ok {
+		return fs
+	}
+	out := make([]float64, len(xs))
+	for i, v := range xs {
+		out[i] = float64(v)
+	}
+	return out
+}
+
+// isFloat reports whether T is a floating-point type: only there does 1/2 survive.
+func isFloat[T Number]() bool {
+	var one T = 1
+	return one/2 != 0
+}
+
+// sumInts adds integers exactly; offset is added to the index in the error.
+func sumInts[T Number](xs []T, offset int) (T, error) {
+	var s T
+	for i, v := range xs {
+		var ok bool
+		if s, ok = addChecked(s, v); !ok {
+			return 0, fmt.Errorf("%w at index %d", ErrOverflow, offset+i)
+		}
+	}
+	return s, nil
+}
+
+// addChecked returns a+b and false if the integer addition wrapped around.
+func addChecked[T Number](a, b T) (T, bool) {
+	r := a + b
+	if (b > 0 && r < a) || (b < 0 && r > a) {
+		return 0, false
+	}
+	return r, true
+}
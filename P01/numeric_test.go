@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestGenericInts(t *testing.T) {
+	counts := []int64{3, 1, 4, 1, 5, 9, 2, 6}
+	sum, err := Sum(counts)
+	if err != nil || sum != 31 {
+		t.Fatalf("sum got=%v err=%v, want 31", sum, err)
+	}
+	mean, _ := Mean(counts)
+	median, _ := Median(counts)
+	p90, _ := Quantile(counts, 0.9)
+	if mean != 3.875 || median != 3.5 || !almostEqual(p90, 6.9, 1e-12) {
+		t.Fatalf("mean=%v median=%v p90=%v", mean, median, p90)
+	}
+
+	small := []uint8{200, 50}
+	if s, err := Sum(small); err != nil || s != 250 {
+		t.Fatalf("uint8 sum got=%v err=%v", s, err)
+	}
+}
+
+func TestGenericDurations(t *testing.T) {
+	lat := []time.Duration{120 * time.Millisecond, 80 * time.Millisecond, 100 * time.Millisecond}
+	total, err := Sum(lat)
+	if err != nil || total != 300*time.Millisecond {
+		t.Fatalf("sum got=%v err=%v", total, err)
+	}
+	if s, ok := any(total).(time.Duration); !ok || s.String() != "300ms" {
+		t.Fatalf("sum should stay a time.Duration, got %T", any(total))
+	}
+	median, _ := Median(lat)
+	if time.Duration(median) != 100*time.Millisecond {
+		t.Fatalf("median got=%v", time.Duration(median))
+	}
+	std, _ := Std(lat)
+	if want := float64(16329931); math.Abs(std-want) > 1 {
+		t.Fatalf("std got=%v, want≈%v", std, want)
+	}
+}
+
+func TestGenericFloat32(t *testing.T) {
+	xs := []float32{1.5, 2.5, float32(math.NaN())}
+	if _, err := Mean(xs); !errors.Is(err, ErrNonFinite) {
+		t.Fatalf("err=%v, want ErrNonFinite", err)
+	}
+	s, err := Sum(xs, WithNonFinite(SkipNonFinite))
+	if err != nil || s != 4 {
+		t.Fatalf("sum got=%v err=%v", s, err)
+	}
+}
+
+func TestSumOverflow(t *testing.T) {
+	tests := []struct {
+		name string
+		sum  func() error
+	}{
+		{"int8 up", func() error { _, err := Sum([]int8{100, 27, 1}); return err }},
+		{"int8 down", func() error { _, err := Sum([]int8{-100, -28, -1}); return err }},
+		{"uint16", func() error { _, err := Sum([]uint16{65535, 1}); return err }},
+		{"int64", func() error { _, err := Sum([]int64{math.MaxInt64, 1}); return err }},
+		{"duration", func() error { _, err := Sum([]time.Duration{math.MaxInt64, time.Nanosecond}); return err }},
+		{"parallel", func() error {
+			xs := make([]int64, 20_000)
+			xs[15_000] = math.MaxInt64
+			xs[19_000] = 1
+			_, err := ParallelSum(xs, WithWorkers(4))
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.sum(); !errors.Is(err, ErrOverflow) {
+				t.Fatalf("err=%v, want ErrOverflow", err)
+			}
+		})
+	}
+
+	// only the running sum is checked: 127-1+1 never leaves the int8 range
+	if s, err := Sum([]int8{127, -1, 1}); err != nil || s != 127 {
+		t.Fatalf("got=%v err=%v, want 127", s, err)
+	}
+	if _, err := Sum([]int{}); !errors.Is(err, ErrEmptySlice) {
+		t.Fatalf("empty: err=%v", err)
+	}
+}
+
+func TestParallelSumInts(t *testing.T) {
+	xs := make([]int, 100_000)
+	for i := range xs {
+		xs[i] = i
+	}
+	got, err := ParallelSum(xs, WithWorkers(8))
+	if err != nil || got != 99_999*100_000/2 {
+		t.Fatalf("got=%v err=%v", got, err)
+	}
+}
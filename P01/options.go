@@ -0,0 +1,58 @@
+package main
+
+import "runtime"
+
+// Option tunes how a statistics function treats its input.
+type Option func(*options)
+
+type options struct {
+	method    QuantileMethod
+	nonFinite NonFinitePolicy
+	ddof      int
+	workers   int
+}
+
+// NonFinitePolicy decides what happens to NaN and ±Inf inputs.
+type NonFinitePolicy int
+
+const (
+	// RejectNonFinite fails with a *NonFiniteError naming the first bad index.
+	RejectNonFinite NonFinitePolicy = iota
+	// SkipNonFinite drops NaN and ±Inf and computes over the rest.
+	SkipNonFinite
+	// PropagateNonFinite follows IEEE 754: a NaN input yields a NaN result.
+	PropagateNonFinite
+)
+
+func buildOptions(opts []Option) options {
+	o := options{
+		method:    DefaultQuantileMethod,
+		nonFinite: RejectNonFinite,
+		workers:   runtime.GOMAXPROCS(0),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithMethod selects the interpolation method used by Quantile and Percentiles.
+func WithMethod(m QuantileMethod) Option {
+	return func(o *options) { o.method = m }
+}
+
+// WithNonFinite sets the NaN/Inf policy; the default is RejectNonFinite.
+func WithNonFinite(p NonFinitePolicy) Option {
+	return func(o *options) { o.nonFinite = p }
+}
+
+// WithDDOF sets the delta degrees of freedom: variance-like statistics divide
+// by n-ddof. The default 0 gives the population variance, 1 the sample variance.
+func WithDDOF(ddof int) Option {
+	return func(o *options) { o.ddof = ddof }
+}
+
+// WithWorkers sets how many goroutines the Parallel* functions may use.
+func WithWorkers(n int) Option {
+	return func(o *options) { o.workers = n }
+}
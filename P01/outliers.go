@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"math"
+	"sync/atomic"
+)
+
+// Outlier is a value flagged by one of the detectors, with its input index.
+type Outlier struct {
+	Index int
+	Value float64
+}
+
+// Detector is the common shape of the outlier tests, so a pipeline can take
+// any of them, e.g. func(xs []float64) ([]Outlier, error) { return IQROutliers(xs, 1.5) }.
+type Detector func(xs []float64) ([]Outlier, error)
+
+// ZScoreOutliers flags values more than threshold standard deviations from
+// the mean (3 is customary). Constant input has no outliers.
+func ZScoreOutliers[T Number](data []T, threshold float64, opts ...Option) ([]Outlier, error) {
+	xs, pos, err := prepareIndexed(floats(data), buildOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	m := mean(xs)
+	sd := math.Sqrt(variance(xs, 0))
+	if sd == 0 {
+		return nil, nil
+	}
+	return flagWhere(xs, pos, func(v float64) bool { return math.Abs(v-m)/sd > threshold }), nil
+}
+
+// ModifiedZScoreOutliers uses the robust score 0.6745*(x-median)/MAD of
+// Iglewicz and Hoaglin (3.5 is customary). When the MAD is zero it falls back
+// to the mean absolute deviation scaled by 1.2533.
+func ModifiedZScoreOutliers[T Number](data []T, threshold float64, opts ...Option) ([]Outlier, error) {
+	xs, pos, err := prepareIndexed(floats(data), buildOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	med := median(xs)
+	scale := medianAbsDev(xs) / 0.6745
+	if scale == 0 {
+		var acc neumaier
+		for _, v := range xs {
+			acc.add(math.Abs(v - med))
+		}
+		scale = 1.253314 * acc.value() / float64(len(xs))
+	}
+	if scale == 0 {
+		return nil, nil
+	}
+	return flagWhere(xs, pos, func(v float64) bool { return math.Abs(v-med)/scale > threshold }), nil
+}
+
+// IQROutliers flags values outside Tukey's fences [Q1-k*IQR, Q3+k*IQR]
+// (k=1.5 for "outliers", 3 for "far out"). The quartiles follow opts too: a
+// NaN under PropagateNonFinite makes the fences NaN, so nothing is flagged.
+func IQROutliers[T Number](data []T, k float64, opts ...Option) ([]Outlier, error) {
+	xs, pos, err := prepareIndexed(floats(data), buildOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	qs, err := PercentilesWith(xs, []float64{25, 75}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	iqr := qs[1] - qs[0]
+	lo, hi := qs[0]-k*iqr, qs[1]+k*iqr
+	return flagWhere(xs, pos, func(v float64) bool { return v < lo || v > hi }), nil
+}
+
+// GrubbsOutliers runs the two-sided Grubbs test at significance alpha
+// repeatedly, removing the most extreme value each time it is significant.
+// It assumes the rest of the data is roughly normal and needs at least 3
+// values; alpha must be within (0, 1).
+func GrubbsOutliers[T Number](data []T, alpha float64, opts ...Option) ([]Outlier, error) {
+	xs, pos, err := prepareIndexed(floats(data), buildOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	if !(alpha > 0 && alpha < 1) { // also rejects NaN
+		return nil, ErrInvalidAlpha
+	}
+	if len(xs) < 3 {
+		return nil, ErrTooFewValues
+	}
+
+	idx := make([]int, len(xs))
+	for i := range idx {
+		idx[i] = i
+	}
+	var out []Outlier
+	for len(idx) >= 3 {
+		var acc Accumulator
+		for _, i := range idx {
+			acc.Add(xs[i])
+		}
+		n := float64(len(idx))
+		m, _ := acc.Mean()
+		v, _ := acc.Variance()
+		sd := math.Sqrt(v * n / (n - 1))
+		if sd == 0 {
+			break
+		}
+
+		worst := 0
+		for j, i := range idx {
+			if math.Abs(xs[i]-m) > math.Abs(xs[idx[worst]]-m) {
+				worst = j
+			}
+		}
+		g := math.Abs(xs[idx[worst]]-m) / sd
+
+		t := studentTQuantile(1-alpha/(2*n), n-2)
+		crit := (n - 1) / math.Sqrt(n) * math.Sqrt(t*t/(n-2+t*t))
+		if math.IsNaN(crit) || g <= crit { // NaN: the t quantile overflowed
+			break
+		}
+		out = append(out, Outlier{Index: inputIndex(pos, idx[worst]), Value: xs[idx[worst]]})
+		idx = append(idx[:worst], idx[worst+1:]...)
+	}
+	return out, nil
+}
+
+// prepareIndexed is prepare for the detectors. pos maps every value kept to
+// its index in data, so outliers point into the caller's slice even when
+// SkipNonFinite drops values; it is nil when nothing was dropped.
+func prepareIndexed(data []float64, o options) (xs []float64, pos []int, err error) {
+	xs, err = prepare(data, o)
+	if err != nil || len(xs) == len(data) {
+		return xs, nil, err
+	}
+	pos = make([]int, 0, len(xs))
+	for i, v := range data {
+		if isFinite(v) {
+			pos = append(pos, i)
+		}
+	}
+	return xs, pos, nil
+}
+
+func inputIndex(pos []int, i int) int {
+	if pos == nil {
+		return i
+	}
+	return pos[i]
+}
+
+// flagWhere collects the values for which bad reports true, indexed as in
+// the input through pos.
+func flagWhere(xs []float64, pos []int, bad func(float64) bool) []Outlier {
+	var out []Outlier
+	for i, v := range xs {
+		if bad(v) {
+			out = append(out, Outlier{Index: inputIndex(pos, i), Value: v})
+		}
+	}
+	return out
+}
+
+// RejectOutliers splits xs into the values detect accepts and the ones it flags.
+func RejectOutliers(xs []float64, detect Detector) ([]float64, []Outlier, error) {
+	outliers, err := detect(xs)
+	if err != nil {
+		return nil, nil, err
+	}
+	drop := make(map[int]bool, len(outliers))
+	for _, o := range outliers {
+		drop[o.Index] = true
+	}
+	kept := make([]float64, 0, len(xs)-len(drop))
+	for i, v := range xs {
+		if !drop[i] {
+			kept = append(kept, v)
+		}
+	}
+	return kept, outliers, nil
+}
+
+// OutlierFilter is a pipeline stage like the fixed-bound Filter of P05, but
+// statistically driven: every incoming sample is tested together with the
+// last window finite samples and dropped when detect flags it. Dropped
+// samples still enter the window, so after a level shift the filter follows
+// the new level once it dominates the window instead of rejecting it forever.
+// Samples pass unchecked until the window has filled once; NaN and ±Inf are
+// always dropped and never enter the window.
+// Drops are counted in dropped unless it is nil. The output is closed when in
+// is closed or ctx is canceled.
+func OutlierFilter(ctx context.Context, in <-chan Sample, window int, detect Detector, dropped *int64) (<-chan Sample, error) {
+	if window < 1 {
+		return nil, ErrInvalidWindow
+	}
+	drop := func() {
+		if dropped != nil {
+			atomic.AddInt64(dropped, 1)
+		}
+	}
+	out := make(chan Sample)
+	go func() {
+		defer close(out)
+		recent := make([]float64, 0, window+1)
+		for {
+			var s Sample
+			select {
+			case v, ok := <-in:
+				if !ok {
+					return
+				}
+				s = v
+			case <-ctx.Done():
+				return
+			}
+			if !isFinite(s.Value) {
+				drop()
+				continue
+			}
+			reject := false
+			if len(recent) == window {
+				outliers, err := detect(append(recent, s.Value))
+				reject = err == nil && flagged(outliers, window)
+				recent = append(recent[:0], recent[1:]...)
+			}
+			recent = append(recent, s.Value)
+			if reject {
+				drop()
+				continue
+			}
+
+			select {
+			case out <- s:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+func flagged(outliers []Outlier, index int) bool {
+	for _, o := range outliers {
+		if o.Index == index {
+			return true
+		}
+	}
+	return false
+}
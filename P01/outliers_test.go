@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOutlierDetectors(t *testing.T) {
+	base := []float64{10, 12, 11, 13, 12, 11, 10, 12, 11, 13}
+	spiked := append(append([]float64(nil), base...), 45)
+	// NIST example data set for Grubbs' test
+	nist := []float64{199.31, 199.53, 200.19, 200.82, 201.92, 201.95, 202.18, 245.57}
+
+	tests := []struct {
+		name   string
+		detect Detector
+		in     []float64
+		want   []Outlier
+		err    error
+	}{
+		{"zscore spike", func(xs []float64) ([]Outlier, error) { return ZScoreOutliers(xs, 3) }, spiked, []Outlier{{10, 45}}, nil},
+		{"zscore clean", func(xs []float64) ([]Outlier, error) { return ZScoreOutliers(xs, 3) }, base, nil, nil},
+		{"zscore constant", func(xs []float64) ([]Outlier, error) { return ZScoreOutliers(xs, 3) }, []float64{5, 5, 5}, nil, nil},
+		{"modified zscore", func(xs []float64) ([]Outlier, error) { return ModifiedZScoreOutliers(xs, 3.5) }, spiked, []Outlier{{10, 45}}, nil},
+		{"modified zscore mad=0", func(xs []float64) ([]Outlier, error) { return ModifiedZScoreOutliers(xs, 3.5) }, []float64{5, 5, 5, 5, 5, 100}, []Outlier{{5, 100}}, nil},
+		{"iqr", func(xs []float64) ([]Outlier, error) { return IQROutliers(xs, 1.5) }, []float64{-20, 1, 2, 3, 4, 5, 6, 7, 8, 30}, []Outlier{{0, -20}, {9, 30}}, nil},
+		{"grubbs nist", func(xs []float64) ([]Outlier, error) { return GrubbsOutliers(xs, 0.05) }, nist, []Outlier{{7, 245.57}}, nil},
+		{"grubbs clean", func(xs []float64) ([]Outlier, error) { return GrubbsOutliers(xs, 0.05) }, nist[:7], nil, nil},
+		{"grubbs alpha=0", func(xs []float64) ([]Outlier, error) { return GrubbsOutliers(xs, 0) }, nist, nil, ErrInvalidAlpha},
+		{"grubbs alpha>1", func(xs []float64) ([]Outlier, error) { return GrubbsOutliers(xs, 1.5) }, nist, nil, ErrInvalidAlpha},
+		{"grubbs tiny alpha", func(xs []float64) ([]Outlier, error) { return GrubbsOutliers(xs, 1e-300) }, nist, nil, nil},
+		{"grubbs too few", func(xs []float64) ([]Outlier, error) { return GrubbsOutliers(xs, 0.05) }, []float64{1, 2}, nil, ErrTooFewValues},
+		{"empty", func(xs []float64) ([]Outlier, error) { return IQROutliers(xs, 1.5) }, nil, nil, ErrEmptySlice},
+		{"iqr skip nan", func(xs []float64) ([]Outlier, error) { return IQROutliers(xs, 1.5, WithNonFinite(SkipNonFinite)) }, []float64{math.NaN(), 1, 2, 3, 4, 5, 6, 7, 8, 100}, []Outlier{{9, 100}}, nil},
+		{"iqr propagate nan", func(xs []float64) ([]Outlier, error) { return IQROutliers(xs, 1.5, WithNonFinite(PropagateNonFinite)) }, []float64{1, 2, 3, math.NaN(), 4, 5, 100}, nil, nil},
+		{"iqr propagate inf", func(xs []float64) ([]Outlier, error) { return IQROutliers(xs, 1.5, WithNonFinite(PropagateNonFinite)) }, []float64{1, 2, 3, 4, 5, 6, 7, 8, math.Inf(1)}, []Outlier{{8, math.Inf(1)}}, nil},
+		{"zscore skip inf", func(xs []float64) ([]Outlier, error) { return ZScoreOutliers(xs, 3, WithNonFinite(SkipNonFinite)) }, append([]float64{math.Inf(1)}, spiked...), []Outlier{{11, 45}}, nil},
+		{"grubbs skip nan", func(xs []float64) ([]Outlier, error) { return GrubbsOutliers(xs, 0.05, WithNonFinite(SkipNonFinite)) }, append([]float64{math.NaN()}, nist...), []Outlier{{8, 245.57}}, nil},
+		{"nan", func(xs []float64) ([]Outlier, error) { return ZScoreOutliers(xs, 3) }, []float64{1, math.NaN()}, nil, ErrNonFinite},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.detect(tt.in)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err=%v, want %v", err, tt.err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got=%v, want=%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrubbsRepeats(t *testing.T) {
+	xs := []float64{10, 10.2, 9.9, 10.1, 9.8, 10, 10.3, 9.7, 10.1, 25, -8}
+	got, err := GrubbsOutliers(xs, 0.05)
+	if err != nil {
+		t.Fatalf("err=%v", err)
+	}
+	want := []Outlier{{10, -8}, {9, 25}} // -8 is further from the mean, so it goes first
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got=%v, want=%v", got, want)
+	}
+}
+
+func TestStudentTQuantile(t *testing.T) {
+	tests := []struct {
+		p, df, want float64
+	}{
+		{0.975, 10, 2.228138851986273},
+		{0.995, 5, 4.032142983557536},
+		{0.05, 3, -2.353363434801823},
+		{0.5, 7, 0},
+	}
+	for _, tt := range tests {
+		if got := studentTQuantile(tt.p, tt.df); !almostEqual(got, tt.want, 1e-9) {
+			t.Fatalf("p=%v df=%v got=%v, want=%v", tt.p, tt.df, got, tt.want)
+		}
+	}
+}
+
+func TestRejectOutliers(t *testing.T) {
+	kept, out, err := RejectOutliers([]float64{1, 2, 3, 100, 2}, func(xs []float64) ([]Outlier, error) {
+		return IQROutliers(xs, 1.5)
+	})
+	if err != nil {
+		t.Fatalf("err=%v", err)
+	}
+	if !reflect.DeepEqual(kept, []float64{1, 2, 3, 2}) || len(out) != 1 || out[0].Index != 3 {
+		t.Fatalf("kept=%v out=%v", kept, out)
+	}
+}
+
+func TestRejectOutliersSkip(t *testing.T) {
+	kept, _, err := RejectOutliers([]float64{math.NaN(), 1, 2, 3, 100, 2}, func(xs []float64) ([]Outlier, error) {
+		return IQROutliers(xs, 1.5, WithNonFinite(SkipNonFinite))
+	})
+	if err != nil {
+		t.Fatalf("err=%v", err)
+	}
+	if len(kept) != 5 || !math.IsNaN(kept[0]) || !reflect.DeepEqual(kept[1:], []float64{1, 2, 3, 2}) {
+		t.Fatalf("kept=%v", kept)
+	}
+}
+
+func TestOutlierFilter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	values := []float64{20, 21, 19, 20, 22, 21, 20, 90, 21, math.NaN(), 19, -40, 20}
+	in := make(chan Sample)
+	go func() {
+		defer close(in)
+		for _, v := range values {
+			in <- Sample{Value: v}
+		}
+	}()
+
+	var dropped int64
+	detect := func(xs []float64) ([]Outlier, error) { return ModifiedZScoreOutliers(xs, 3.5) }
+	out, err := OutlierFilter(ctx, in, 5, detect, &dropped)
+	if err != nil {
+		t.Fatalf("err=%v", err)
+	}
+	var got []float64
+	for s := range out {
+		got = append(got, s.Value)
+	}
+	want := []float64{20, 21, 19, 20, 22, 21, 20, 21, 19, 20}
+	if !reflect.DeepEqual(got, want) || dropped != 3 {
+		t.Fatalf("got=%v dropped=%d, want=%v dropped=3", got, dropped, want)
+	}
+}
+
+func TestOutlierFilterLevelShift(t *testing.T) {
+	// rejected samples still slide into the window, so after a shift to a new
+	// level the filter follows it instead of dropping everything from then on
+	values := []float64{20, 21, 19, 20, 22}
+	for i := 0; i < 50; i++ {
+		values = append(values, 100+float64(i%3-1))
+	}
+	in := make(chan Sample)
+	go func() {
+		defer close(in)
+		for _, v := range values {
+			in <- Sample{Value: v}
+		}
+	}()
+
+	var dropped int64
+	detect := func(xs []float64) ([]Outlier, error) { return ModifiedZScoreOutliers(xs, 3.5) }
+	out, err := OutlierFilter(context.Background(), in, 5, detect, &dropped)
+	if err != nil {
+		t.Fatalf("err=%v", err)
+	}
+	var got []float64
+	for s := range out {
+		got = append(got, s.Value)
+	}
+	if dropped > 5 || len(got) != len(values)-int(dropped) || got[len(got)-1] != values[len(values)-1] {
+		t.Fatalf("got %d samples (last %v), dropped=%d; want the new level to pass after at most 5 drops", len(got), got[len(got)-1], dropped)
+	}
+}
+
+func TestOutlierFilterArgs(t *testing.T) {
+	detect := func(xs []float64) ([]Outlier, error) { return IQROutliers(xs, 1.5) }
+	for _, window := range []int{0, -2} {
+		if _, err := OutlierFilter(context.Background(), nil, window, detect, nil); !errors.Is(err, ErrInvalidWindow) {
+			t.Fatalf("window=%d: err=%v", window, err)
+		}
+	}
+
+	in := make(chan Sample, 1)
+	in <- Sample{Value: math.NaN()}
+	close(in)
+	out, err := OutlierFilter(context.Background(), in, 1, detect, nil) // nil dropped is allowed
+	if err != nil {
+		t.Fatalf("err=%v", err)
+	}
+	for range out {
+		t.Fatal("NaN was not dropped")
+	}
+}
+
+func TestOutlierFilterCancelIdle(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan Sample) // never sends and is never closed
+	out, err := OutlierFilter(ctx, in, 3, func(xs []float64) ([]Outlier, error) { return nil, nil }, nil)
+	if err != nil {
+		t.Fatalf("err=%v", err)
+	}
+	cancel()
+	select {
+	case _, ok := <-out:
+		if ok {
+			t.Fatal("unexpected sample")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("output still open after cancel with an idle input")
+	}
+}
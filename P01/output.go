@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+const defaultStats = "count,sum,mean,median,std,min,max,p90,p95,p99"
+
+// statSpec is one entry of the -stats list; q is set for percentiles (pNN).
+type statSpec struct {
+	name string
+	q    float64
+}
+
+type statResult struct {
+	Name  string
+	Value float64
+}
+
+func parseStats(list string) ([]statSpec, error) {
+	var specs []statSpec
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "":
+			continue
+		case "count", "sum", "mean", "median", "std", "var", "min", "max":
+			specs = append(specs, statSpec{name: name})
+			continue
+		}
+		if p, ok := strings.CutPrefix(name, "p"); ok {
+			q, err := strconv.ParseFloat(p, 64)
+			if err == nil && q >= 0 && q <= 100 {
+				specs = append(specs, statSpec{name: name, q: q})
+				continue
+			}
+		}
+		return nil, fmt.Errorf("unknown stat %q", name)
+	}
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("no stats selected")
+	}
+	return specs, nil
+}
+
+func computeStats(xs []float64, specs []statSpec) ([]statResult, error) {
+	if err := requireNonEmpty(xs); err != nil {
+		return nil, err
+	}
+	var acc Accumulator
+	for _, v := range xs {
+		acc.Add(v)
+	}
+	var ps []float64
+	for _, s := range specs {
+		if strings.HasPrefix(s.name, "p") {
+			ps = append(ps, s.q)
+		}
+	}
+	pv, err := Percentiles(xs, ps...)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]statResult, 0, len(specs))
+	for _, s := range specs {
+		var v float64
+		switch s.name {
+		case "count":
+			v = float64(acc.Count())
+		case "sum":
+			v, err = Sum(xs)
+		case "mean":
+			v, err = Mean(xs)
+		case "median":
+			v, err = Median(xs)
+		case "std":
+			v, err = Std(xs)
+		case "var":
+			v, err = Variance(xs)
+		case "min":
+			v, err = acc.Min()
+		case "max":
+			v, err = acc.Max()
+		default:
+			v, pv = pv[0], pv[1:]
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", s.name, err)
+		}
+		out = append(out, statResult{Name: s.name, Value: v})
+	}
+	return out, nil
+}
+
+func writeResults(w io.Writer, format string, results []statResult) error {
+	switch format {
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, r := range results {
+			fmt.Fprintf(tw, "%s\t%s\n", r.Name, formatValue(r.Value))
+		}
+		return tw.Flush()
+	case "json":
+		// built by hand so keys keep the order given in -stats; JSON has no
+		// NaN or Inf, so those become null
+		var buf bytes.Buffer
+		buf.WriteByte('{')
+		for i, r := range results {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			k, _ := json.Marshal(r.Name)
+			buf.Write(k)
+			buf.WriteByte(':')
+			if isFinite(r.Value) {
+				buf.WriteString(formatValue(r.Value))
+			} else {
+				buf.WriteString("null")
+			}
+		}
+		buf.WriteString("}\n")
+		_, err := w.Write(buf.Bytes())
+		return err
+	case "csv":
+		cw := csv.NewWriter(w)
+		names := make([]string, len(results))
+		values := make([]string, len(results))
+		for i, r := range results {
+			names[i], values[i] = r.Name, formatValue(r.Value)
+		}
+		_ = cw.Write(names)
+		_ = cw.Write(values)
+		cw.Flush()
+		return cw.Error()
+	}
+	return fmt.Errorf("unknown output format %q", format)
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
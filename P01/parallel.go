@@ -0,0 +1,163 @@
+package main
+
+import "sync"
+
+// minParallelChunk keeps chunks large enough that a goroutine pays for itself.
+const minParallelChunk = 1 << 12
+
+// ParallelSum is Sum split across goroutines (see WithWorkers). Integer
+// chunks are summed exactly and checked for overflow like Sum.
+func ParallelSum[T Number](data []T, opts ...Option) (T, error) {
+	o := buildOptions(opts)
+	if !isFloat[T]() {
+		return parallelSumInts(data, o.workers)
+	}
+	m, err := parallelMoments(floats(data), o, false)
+	if err != nil {
+		return 0, err
+	}
+	return T(m.sum.value()), nil
+}
+
+// ParallelMean is Mean split across goroutines (see WithWorkers).
+func ParallelMean[T Number](data []T, opts ...Option) (float64, error) {
+	m, err := parallelMoments(floats(data), buildOptions(opts), false)
+	if err != nil {
+		return 0, err
+	}
+	return m.sum.value() / float64(m.n), nil
+}
+
+// ParallelVariance is Variance split across goroutines: every chunk computes
+// its own count, mean and squared deviations, and the partial moments are
+// merged with the pairwise update of Chan et al., so no second global pass
+// over the data is needed.
+func ParallelVariance[T Number](data []T, opts ...Option) (float64, error) {
+	o := buildOptions(opts)
+	m, err := parallelMoments(floats(data), o, true)
+	if err != nil {
+		return 0, err
+	}
+	if err := requireDOF(m.n, o.ddof); err != nil {
+		return 0, err
+	}
+	return m.m2 / float64(m.n-o.ddof), nil
+}
+
+// moments are the partial results of one chunk.
+type moments struct {
+	n    int
+	sum  neumaier
+	mean float64
+	m2   float64
+	bad  int // index of the first rejected value, -1 if none
+}
+
+func parallelMoments(xs []float64, o options, withM2 bool) (moments, error) {
+	if err := requireNonEmpty(xs); err != nil {
+		return moments{}, err
+	}
+	workers := max(1, min(o.workers, (len(xs)+minParallelChunk-1)/minParallelChunk))
+
+	parts := make([]moments, workers)
+	var wg sync.WaitGroup
+	for w := range parts {
+		lo, hi := w*len(xs)/workers, (w+1)*len(xs)/workers
+		wg.Add(1)
+		go func(w, lo, hi int) {
+			defer wg.Done()
+			parts[w] = chunkMoments(xs[lo:hi], lo, o.nonFinite, withM2)
+		}(w, lo, hi)
+	}
+	wg.Wait()
+
+	total := moments{bad: -1}
+	for _, p := range parts { // chunks are in order, so the first bad index wins
+		if p.bad >= 0 {
+			return moments{}, &NonFiniteError{Index: p.bad, Value: xs[p.bad]}
+		}
+		total.merge(p)
+	}
+	if total.n == 0 {
+		return moments{}, ErrEmptySlice
+	}
+	return total, nil
+}
+
+func parallelSumInts[T Number](xs []T, workers int) (T, error) {
+	if err := requireNonEmpty(xs); err != nil {
+		return 0, err
+	}
+	workers = max(1, min(workers, (len(xs)+minParallelChunk-1)/minParallelChunk))
+
+	sums := make([]T, workers)
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for w := range sums {
+		lo, hi := w*len(xs)/workers, (w+1)*len(xs)/workers
+		wg.Add(1)
+		go func(w, lo, hi int) {
+			defer wg.Done()
+			sums[w], errs[w] = sumInts(xs[lo:hi], lo)
+		}(w, lo, hi)
+	}
+	wg.Wait()
+
+	var total T
+	for w, s := range sums {
+		if errs[w] != nil {
+			return 0, errs[w]
+		}
+		var ok bool
+		if total, ok = addChecked(total, s); !ok {
+			return 0, ErrOverflow
+		}
+	}
+	return total, nil
+}
+
+func chunkMoments(xs []float64, offset int, policy NonFinitePolicy, withM2 bool) moments {
+	m := moments{bad: -1}
+	for i, v := range xs {
+		if policy != PropagateNonFinite && !isFinite(v) {
+			if policy == RejectNonFinite {
+				m.bad = offset + i
+				return m
+			}
+			continue
+		}
+		m.n++
+		m.sum.add(v)
+	}
+	if m.n == 0 || !withM2 {
+		return m
+	}
+	m.mean = m.sum.value() / float64(m.n)
+	var acc neumaier
+	for _, v := range xs {
+		if policy == SkipNonFinite && !isFinite(v) {
+			continue
+		}
+		d := v - m.mean
+		acc.add(d * d)
+	}
+	m.m2 = acc.value()
+	return m
+}
+
+func (m *moments) merge(o moments) {
+	if o.n == 0 {
+		return
+	}
+	m.sum.add(o.sum.sum)
+	m.sum.add(o.sum.c)
+	if m.n == 0 {
+		m.n, m.mean, m.m2 = o.n, o.mean, o.m2
+		return
+	}
+	n := m.n + o.n
+	d := o.mean - m.mean
+	m.m2 += o.m2 + d*d*float64(m.n)*float64(o.n)/float64(n)
+	m.mean += d * float64(o.n) / float64(n)
+	m.n = n
+}
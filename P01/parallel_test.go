@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestParallelMatchesSerial(t *testing.T) {
+	rng := rand.New(rand.NewSource(9))
+	for _, n := range []int{1, 10, 5_000, 100_003} {
+		xs := make([]float64, n)
+		for i := range xs {
+			xs[i] = rng.NormFloat64()*1e3 + 1e6
+		}
+		wantSum, _ := Sum(xs)
+		wantMean, _ := Mean(xs)
+		wantVar, _ := Variance(xs)
+		for _, workers := range []int{1, 3, 8} {
+			gotSum, err := ParallelSum(xs, WithWorkers(workers))
+			if err != nil {
+				t.Fatalf("n=%d workers=%d err=%v", n, workers, err)
+			}
+			gotMean, _ := ParallelMean(xs, WithWorkers(workers))
+			gotVar, _ := ParallelVariance(xs, WithWorkers(workers))
+			if !almostEqual(gotSum, wantSum, 1e-15) {
+				t.Fatalf("n=%d workers=%d sum got=%v, want=%v", n, workers, gotSum, wantSum)
+			}
+			if !almostEqual(gotMean, wantMean, 1e-15) {
+				t.Fatalf("n=%d workers=%d mean got=%v, want=%v", n, workers, gotMean, wantMean)
+			}
+			if !almostEqual(gotVar, wantVar, 1e-9) {
+				t.Fatalf("n=%d workers=%d variance got=%v, want=%v", n, workers, gotVar, wantVar)
+			}
+		}
+	}
+}
+
+func TestParallelOptions(t *testing.T) {
+	xs := make([]float64, 50_000)
+	for i := range xs {
+		xs[i] = float64(i % 10)
+	}
+	xs[30_000] = math.NaN()
+	xs[40_000] = math.Inf(1)
+
+	_, err := ParallelMean(xs, WithWorkers(8))
+	var nf *NonFiniteError
+	if !errors.As(err, &nf) || nf.Index != 30_000 {
+		t.Fatalf("reject: err=%v, want index 30000", err)
+	}
+
+	clean := append(append([]float64(nil), xs[:30_000]...), xs[30_001:40_000]...)
+	clean = append(clean, xs[40_001:]...)
+	want, _ := SampleVariance(clean)
+	got, err := ParallelVariance(xs, WithWorkers(8), WithNonFinite(SkipNonFinite), WithDDOF(1))
+	if err != nil || !almostEqual(got, want, 1e-12) {
+		t.Fatalf("skip: got=%v err=%v, want=%v", got, err, want)
+	}
+
+	got, err = ParallelSum(xs, WithNonFinite(PropagateNonFinite))
+	if err != nil || !math.IsNaN(got) {
+		t.Fatalf("propagate: got=%v err=%v, want NaN", got, err)
+	}
+
+	if _, err := ParallelSum([]float64(nil)); !errors.Is(err, ErrEmptySlice) {
+		t.Fatalf("empty: err=%v", err)
+	}
+}
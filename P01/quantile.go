@@ -0,0 +1,115 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+// QuantileMethod selects how a quantile falling between two samples is estimated.
+type QuantileMethod int
+
+const (
+	// QuantileR7 interpolates linearly between order statistics at h=(n-1)q.
+	// It is the default in R and NumPy.
+	QuantileR7 QuantileMethod = iota
+	// QuantileNearestRank returns the sample at rank ceil(q*n), without interpolation.
+	QuantileNearestRank
+	// QuantileLinear interpolates the empirical CDF linearly (R type 4, h=n*q).
+	QuantileLinear
+)
+
+const DefaultQuantileMethod = QuantileR7
+
+func (m QuantileMethod) String() string {
+	switch m {
+	case QuantileR7:
+		return "r7"
+	case QuantileNearestRank:
+		return "nearest-rank"
+	case QuantileLinear:
+		return "linear"
+	}
+	return "unknown"
+}
+
+// position maps q to a 0-based index into the sorted sample plus the
+// fraction of the way towards the next element.
+func (m QuantileMethod) position(n int, q float64) (int, float64) {
+	var h float64
+	switch m {
+	case QuantileNearestRank:
+		h = math.Ceil(q*float64(n)) - 1
+	case QuantileLinear:
+		h = q*float64(n) - 1
+	default:
+		h = q * float64(n-1)
+	}
+	if h <= 0 {
+		return 0, 0
+	}
+	if h >= float64(n-1) {
+		return n - 1, 0
+	}
+	i := math.Floor(h)
+	return int(i), h - i
+}
+
+func Quantile[T Number](data []T, q float64, opts ...Option) (float64, error) {
+	o := buildOptions(opts)
+	xs, err := prepare(floats(data), o)
+	if err != nil {
+		return 0, err
+	}
+	if err := requireQuantile(q); err != nil {
+		return 0, err
+	}
+	if o.nonFinite == PropagateNonFinite && hasNaN(xs) {
+		return math.NaN(), nil
+	}
+
+	cp := append([]float64(nil), xs...)
+	i, frac := o.method.position(len(cp), q)
+	lo := selectKth(cp, i)
+	if frac == 0 {
+		return lo, nil
+	}
+	hi := minFloat(cp[i+1:])
+	return lo + frac*(hi-lo), nil
+}
+
+// Percentiles returns the requested percentiles (0..100) of xs, e.g.
+// Percentiles(xs, 90, 95, 99). The input is sorted once for all of them.
+func Percentiles[T Number](xs []T, ps ...float64) ([]float64, error) {
+	return PercentilesWith(xs, ps)
+}
+
+func PercentilesWith[T Number](data []T, ps []float64, opts ...Option) ([]float64, error) {
+	o := buildOptions(opts)
+	xs, err := prepare(floats(data), o)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range ps {
+		if err := requireQuantile(p / 100); err != nil {
+			return nil, err
+		}
+	}
+	out := make([]float64, len(ps))
+	if o.nonFinite == PropagateNonFinite && hasNaN(xs) {
+		for j := range out {
+			out[j] = math.NaN()
+		}
+		return out, nil
+	}
+
+	cp := append([]float64(nil), xs...)
+	sort.Float64s(cp)
+	for j, p := range ps {
+		i, frac := o.method.position(len(cp), p/100)
+		out[j] = cp[i]
+		if frac > 0 {
+			out[j] += frac * (cp[i+1] - cp[i])
+		}
+	}
+	return out, nil
+}
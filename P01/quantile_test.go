@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestQuantileMethods(t *testing.T) {
+	xs := []float64{7, 3, 10, 1, 5, 9, 2, 8, 4, 6} // 1..10 shuffled
+	tests := []struct {
+		name   string
+		method QuantileMethod
+		q      float64
+		want   float64
+	}{
+		{"r7 p25", QuantileR7, 0.25, 3.25},
+		{"r7 p50", QuantileR7, 0.5, 5.5},
+		{"r7 p90", QuantileR7, 0.9, 9.1},
+		{"nearest p25", QuantileNearestRank, 0.25, 3},
+		{"nearest p50", QuantileNearestRank, 0.5, 5},
+		{"nearest p90", QuantileNearestRank, 0.9, 9},
+		{"linear p25", QuantileLinear, 0.25, 2.5},
+		{"linear p50", QuantileLinear, 0.5, 5},
+		{"linear p90", QuantileLinear, 0.9, 9},
+		{"min", QuantileR7, 0, 1},
+		{"max", QuantileR7, 1, 10},
+		{"linear below first", QuantileLinear, 0.05, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Quantile(xs, tt.q, WithMethod(tt.method))
+			if err != nil {
+				t.Fatalf("err=%v", err)
+			}
+			if !almostEqual(got, tt.want, 1e-12) {
+				t.Fatalf("got=%v, want=%v", got, tt.want)
+			}
+			ps, err := PercentilesWith(xs, []float64{tt.q * 100}, WithMethod(tt.method))
+			if err != nil {
+				t.Fatalf("percentiles err=%v", err)
+			}
+			if !almostEqual(ps[0], tt.want, 1e-12) {
+				t.Fatalf("percentiles got=%v, want=%v", ps[0], tt.want)
+			}
+		})
+	}
+}
+
+func TestQuantileErrors(t *testing.T) {
+	if _, err := Quantile([]float64(nil), 0.5); !errors.Is(err, ErrEmptySlice) {
+		t.Fatalf("empty: err=%v", err)
+	}
+	for _, q := range []float64{-0.1, 1.1} {
+		if _, err := Quantile([]float64{1}, q); !errors.Is(err, ErrInvalidQuantile) {
+			t.Fatalf("q=%v: err=%v", q, err)
+		}
+	}
+	if _, err := Percentiles([]float64{1, 2}, 50, 101); !errors.Is(err, ErrInvalidQuantile) {
+		t.Fatalf("p=101: err=%v", err)
+	}
+}
+
+func TestPercentilesDoesNotMutate(t *testing.T) {
+	xs := []float64{3, 1, 2}
+	got, err := Percentiles(xs, 0, 50, 100)
+	if err != nil {
+		t.Fatalf("err=%v", err)
+	}
+	if got[0] != 1 || got[1] != 2 || got[2] != 3 {
+		t.Fatalf("got=%v", got)
+	}
+	if xs[0] != 3 || xs[1] != 1 || xs[2] != 2 {
+		t.Fatalf("input mutated: %v", xs)
+	}
+}
+
+func TestMedianMatchesSort(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for n := 1; n <= 200; n++ {
+		xs := make([]float64, n)
+		for i := range xs {
+			xs[i] = float64(rng.Intn(20)) // plenty of duplicates
+		}
+		want := medianSort(xs)
+		got, err := Median(xs)
+		if err != nil {
+			t.Fatalf("n=%d err=%v", n, err)
+		}
+		if got != want {
+			t.Fatalf("n=%d got=%v, want=%v", n, got, want)
+		}
+	}
+}
+
+// medianSort is the original copy-and-sort median, kept as a reference.
+func medianSort(xs []float64) float64 {
+	cp := append([]float64(nil), xs...)
+	sort.Float64s(cp)
+	n := len(cp)
+	if n%2 == 1 {
+		return cp[n/2]
+	}
+	return (cp[n/2-1] + cp[n/2]) / 2
+}
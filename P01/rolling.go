@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+)
+
+// Sample is a timestamped value, e.g. a sensor reading or a price tick.
+type Sample struct {
+	At    time.Time
+	Value float64
+}
+
+// WindowStats is a snapshot of a RollingWindow after a push.
+type WindowStats struct {
+	At       time.Time
+	Count    int
+	Mean     float64
+	Variance float64
+	Std      float64
+	Min      float64
+	Max      float64
+	Median   float64
+}
+
+type windowEntry struct {
+	seq int64
+	at  time.Time
+	v   float64
+}
+
+// RollingWindow keeps statistics over the most recent samples, bounded either
+// by count or by age. Mean and variance are updated in O(1) (Welford add and
+// remove), min and max in amortized O(1) with monotonic deques, and the median
+// in O(log n) search plus an O(n) shift of a sorted copy of the window.
+// Values are expected to be finite. It is not safe for concurrent use.
+type RollingWindow struct {
+	size int           // max samples; 0 when bounded by span
+	span time.Duration // max age; 0 when bounded by size
+
+	entries []windowEntry // window contents in arrival order, from head
+	head    int
+	seq     int64
+
+	mean float64
+	m2   float64
+
+	minq, maxq []windowEntry // monotonic deques of candidates
+	sorted     []float64
+}
+
+// NewRollingWindow keeps the last size samples.
+func NewRollingWindow(size int) (*RollingWindow, error) {
+	if size <= 0 {
+		return nil, ErrInvalidWindow
+	}
+	return &RollingWindow{size: size}, nil
+}
+
+// NewTimeWindow keeps the samples newer than span relative to the latest push.
+func NewTimeWindow(span time.Duration) (*RollingWindow, error) {
+	if span <= 0 {
+		return nil, ErrInvalidWindow
+	}
+	return &RollingWindow{span: span}, nil
+}
+
+func (w *RollingWindow) Len() int { return len(w.entries) - w.head }
+
+// Push adds x stamped with the current time.
+func (w *RollingWindow) Push(x float64) { w.PushAt(time.Now(), x) }
+
+// PushAt adds x observed at t and evicts whatever falls out of the window.
+// Samples must be pushed in non-decreasing time order for time windows.
+func (w *RollingWindow) PushAt(t time.Time, x float64) {
+	w.seq++
+	e := windowEntry{seq: w.seq, at: t, v: x}
+	w.entries = append(w.entries, e)
+
+	n := float64(w.Len())
+	d := x - w.mean
+	w.mean += d / n
+	w.m2 += d * (x - w.mean)
+
+	for len(w.minq) > 0 && w.minq[len(w.minq)-1].v >= x {
+		w.minq = w.minq[:len(w.minq)-1]
+	}
+	w.minq = append(w.minq, e)
+	for len(w.maxq) > 0 && w.maxq[len(w.maxq)-1].v <= x {
+		w.maxq = w.maxq[:len(w.maxq)-1]
+	}
+	w.maxq = append(w.maxq, e)
+
+	i := sort.SearchFloat64s(w.sorted, x)
+	w.sorted = append(w.sorted, 0)
+	copy(w.sorted[i+1:], w.sorted[i:])
+	w.sorted[i] = x
+
+	if w.size > 0 {
+		for w.Len() > w.size {
+			w.evict()
+		}
+	} else {
+		cutoff := t.Add(-w.span)
+		for w.Len() > 0 && !w.entries[w.head].at.After(cutoff) {
+			w.evict()
+		}
+	}
+}
+
+func (w *RollingWindow) evict() {
+	old := w.entries[w.head]
+	w.entries[w.head] = windowEntry{}
+	w.head++
+	if w.head > len(w.entries)/2 { // reclaim the consumed prefix
+		w.entries = append(w.entries[:0], w.entries[w.head:]...)
+		w.head = 0
+	}
+
+	n := w.Len()
+	if n == 0 {
+		w.mean, w.m2 = 0, 0
+	} else {
+		d := old.v - w.mean
+		w.mean -= d / float64(n)
+		w.m2 -= d * (old.v - w.mean)
+		w.m2 = math.Max(w.m2, 0) // guard against rounding below zero
+	}
+
+	if len(w.minq) > 0 && w.minq[0].seq == old.seq {
+		w.minq = w.minq[1:]
+	}
+	if len(w.maxq) > 0 && w.maxq[0].seq == old.seq {
+		w.maxq = w.maxq[1:]
+	}
+
+	i := sort.SearchFloat64s(w.sorted, old.v)
+	w.sorted = append(w.sorted[:i], w.sorted[i+1:]...)
+}
+
+func (w *RollingWindow) Mean() (float64, error) {
+	if w.Len() == 0 {
+		return 0, ErrEmptySlice
+	}
+	return w.mean, nil
+}
+
+// Variance is the population variance of the current window.
+func (w *RollingWindow) Variance() (float64, error) {
+	if w.Len() == 0 {
+		return 0, ErrEmptySlice
+	}
+	return w.m2 / float64(w.Len()), nil
+}
+
+func (w *RollingWindow) Std() (float64, error) {
+	v, err := w.Variance()
+	if err != nil {
+		return 0, err
+	}
+	return math.Sqrt(v), nil
+}
+
+func (w *RollingWindow) Min() (float64, error) {
+	if w.Len() == 0 {
+		return 0, ErrEmptySlice
+	}
+	return w.minq[0].v, nil
+}
+
+func (w *RollingWindow) Max() (float64, error) {
+	if w.Len() == 0 {
+		return 0, ErrEmptySlice
+	}
+	return w.maxq[0].v, nil
+}
+
+func (w *RollingWindow) Median() (float64, error) {
+	n := len(w.sorted)
+	if n == 0 {
+		return 0, ErrEmptySlice
+	}
+	if n%2 == 1 {
+		return w.sorted[n/2], nil
+	}
+	return (w.sorted[n/2-1] + w.sorted[n/2]) / 2, nil
+}
+
+// Stats returns all window statistics at once; ok is false for an empty window.
+func (w *RollingWindow) Stats() (WindowStats, bool) {
+	if w.Len() == 0 {
+		return WindowStats{}, false
+	}
+	st := WindowStats{
+		At:    w.entries[len(w.entries)-1].at,
+		Count: w.Len(),
+		Mean:  w.mean,
+	}
+	st.Variance, _ = w.Variance()
+	st.Std = math.Sqrt(st.Variance)
+	st.Min, _ = w.Min()
+	st.Max, _ = w.Max()
+	st.Median, _ = w.Median()
+	return st, true
+}
+
+// RollingSlice returns the window statistics after each element of xs.
+func RollingSlice[T Number](data []T, size int) ([]WindowStats, error) {
+	xs := floats(data)
+	if err := requireNonEmpty(xs); err != nil {
+		return nil, err
+	}
+	if err := requireFinite(xs); err != nil {
+		return nil, err
+	}
+	w, err := NewRollingWindow(size)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]WindowStats, len(xs))
+	for i, v := range xs {
+		w.PushAt(time.Time{}, v)
+		out[i], _ = w.Stats()
+	}
+	return out, nil
+}
+
+// Rolling is a pipeline stage: it pushes every sample from in into w and emits
+// the updated statistics. Non-finite samples are dropped. The output is closed
+// when in is closed or ctx is canceled.
+func Rolling(ctx context.Context, in <-chan Sample, w *RollingWindow) <-chan WindowStats {
+	out := make(chan WindowStats)
+	go func() {
+		defer close(out)
+		for {
+			var s Sample
+			select {
+			case v, ok := <-in:
+				if !ok {
+					return
+				}
+				s = v
+			case <-ctx.Done():
+				return
+			}
+			if !isFinite(s.Value) {
+				continue
+			}
+			w.PushAt(s.At, s.Value)
+			st, _ := w.Stats()
+			select {
+			case out <- st:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestRollingWindowMatchesBatch(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	xs := make([]float64, 500)
+	for i := range xs {
+		xs[i] = float64(rng.Intn(50)) + rng.Float64()
+	}
+	for _, size := range []int{1, 2, 7, 64} {
+		w, err := NewRollingWindow(size)
+		if err != nil {
+			t.Fatalf("size=%d err=%v", size, err)
+		}
+		for i, v := range xs {
+			w.Push(v)
+			win := xs[max(0, i+1-size) : i+1]
+
+			st, ok := w.Stats()
+			if !ok || st.Count != len(win) {
+				t.Fatalf("size=%d i=%d count got=%d, want=%d", size, i, st.Count, len(win))
+			}
+			wantMean, _ := Mean(win)
+			wantVar, _ := Variance(win)
+			wantMed, _ := Median(win)
+			if !almostEqual(st.Mean, wantMean, 1e-9) || !almostEqual(st.Variance, wantVar, 1e-9) {
+				t.Fatalf("size=%d i=%d mean=%v var=%v, want mean=%v var=%v", size, i, st.Mean, st.Variance, wantMean, wantVar)
+			}
+			if st.Median != wantMed {
+				t.Fatalf("size=%d i=%d median got=%v, want=%v", size, i, st.Median, wantMed)
+			}
+			lo, hi := win[0], win[0]
+			for _, v := range win {
+				lo, hi = min(lo, v), max(hi, v)
+			}
+			if st.Min != lo || st.Max != hi {
+				t.Fatalf("size=%d i=%d min/max got=%v/%v, want=%v/%v", size, i, st.Min, st.Max, lo, hi)
+			}
+		}
+	}
+}
+
+func TestTimeWindowEviction(t *testing.T) {
+	w, err := NewTimeWindow(10 * time.Second)
+	if err != nil {
+		t.Fatalf("err=%v", err)
+	}
+	t0 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	w.PushAt(t0, 1)
+	w.PushAt(t0.Add(5*time.Second), 3)
+	w.PushAt(t0.Add(9*time.Second), 5)
+	if w.Len() != 3 {
+		t.Fatalf("len got=%d, want=3", w.Len())
+	}
+
+	// the sample at t0 is exactly 10s old and drops out
+	w.PushAt(t0.Add(10*time.Second), 7)
+	if w.Len() != 3 {
+		t.Fatalf("len got=%d, want=3", w.Len())
+	}
+	if m, _ := w.Mean(); m != 5 {
+		t.Fatalf("mean got=%v, want=5", m)
+	}
+	if lo, _ := w.Min(); lo != 3 {
+		t.Fatalf("min got=%v, want=3", lo)
+	}
+
+	// a long gap empties everything but the newest sample
+	w.PushAt(t0.Add(time.Minute), 2)
+	if w.Len() != 1 {
+		t.Fatalf("len got=%d, want=1", w.Len())
+	}
+	if v, _ := w.Variance(); v != 0 {
+		t.Fatalf("variance got=%v, want=0", v)
+	}
+}
+
+func TestRollingWindowErrors(t *testing.T) {
+	if _, err := NewRollingWindow(0); !errors.Is(err, ErrInvalidWindow) {
+		t.Fatalf("size=0: err=%v", err)
+	}
+	if _, err := NewTimeWindow(0); !errors.Is(err, ErrInvalidWindow) {
+		t.Fatalf("span=0: err=%v", err)
+	}
+	w, _ := NewRollingWindow(3)
+	if _, err := w.Median(); !errors.Is(err, ErrEmptySlice) {
+		t.Fatalf("empty median: err=%v", err)
+	}
+	if _, ok := w.Stats(); ok {
+		t.Fatalf("empty stats reported ok")
+	}
+}
+
+func TestRollingSlice(t *testing.T) {
+	got, err := RollingSlice([]float64{1, 2, 3, 4, 5}, 3)
+	if err != nil {
+		t.Fatalf("err=%v", err)
+	}
+	wantMeans := []float64{1, 1.5, 2, 3, 4}
+	for i, st := range got {
+		if st.Mean != wantMeans[i] {
+			t.Fatalf("i=%d mean got=%v, want=%v", i, st.Mean, wantMeans[i])
+		}
+	}
+}
+
+func TestRollingStream(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	in := make(chan Sample)
+	w, _ := NewRollingWindow(2)
+	out := Rolling(ctx, in, w)
+	go func() {
+		defer close(in)
+		t0 := time.Now()
+		for i, v := range []float64{10, 20, 30} {
+			in <- Sample{At: t0.Add(time.Duration(i) * time.Second), Value: v}
+		}
+	}()
+
+	var means []float64
+	for st := range out {
+		means = append(means, st.Mean)
+	}
+	want := []float64{10, 15, 25}
+	if len(means) != len(want) {
+		t.Fatalf("got %d results, want %d", len(means), len(want))
+	}
+	for i := range want {
+		if means[i] != want[i] {
+			t.Fatalf("means got=%v, want=%v", means, want)
+		}
+	}
+}
+
+func TestRollingCancelIdle(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	w, _ := NewRollingWindow(2)
+	out := Rolling(ctx, make(chan Sample), w) // the input never sends
+	cancel()
+	select {
+	case _, ok := <-out:
+		if ok {
+			t.Fatal("unexpected stats")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("output still open after cancel with an idle input")
+	}
+}
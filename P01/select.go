@@ -0,0 +1,77 @@
+package main
+
+import (
+	"math/bits"
+	"sort"
+)
+
+// selectKth rearranges a in place so that a[k] holds the k-th smallest value,
+// every element before it is <= a[k] and every element after it is >= a[k].
+// It is a quickselect with a median-of-three pivot and three-way partitioning;
+// after 2*log2(n) rounds without converging it falls back to sorting, so the
+// worst case stays O(n log n) while the expected cost is O(n).
+func selectKth(a []float64, k int) float64 {
+	lo, hi := 0, len(a)-1
+	depth := 2 * bits.Len(uint(len(a)))
+	for lo < hi {
+		if depth == 0 {
+			sort.Float64s(a[lo : hi+1])
+			return a[k]
+		}
+		depth--
+
+		lt, gt := partition3(a, lo, hi)
+		switch {
+		case k < lt:
+			hi = lt - 1
+		case k > gt:
+			lo = gt + 1
+		default:
+			return a[k]
+		}
+	}
+	return a[k]
+}
+
+// partition3 splits a[lo..hi] around a median-of-three pivot into
+// < pivot, == pivot (a[lt..gt]) and > pivot.
+func partition3(a []float64, lo, hi int) (lt, gt int) {
+	mid := lo + (hi-lo)/2
+	if a[mid] < a[lo] {
+		a[mid], a[lo] = a[lo], a[mid]
+	}
+	if a[hi] < a[lo] {
+		a[hi], a[lo] = a[lo], a[hi]
+	}
+	if a[hi] < a[mid] {
+		a[hi], a[mid] = a[mid], a[hi]
+	}
+	pivot := a[mid]
+
+	lt, i, gt := lo, lo, hi
+	for i <= gt {
+		switch {
+		case a[i] < pivot:
+			a[lt], a[i] = a[i], a[lt]
+			lt++
+			i++
+		case a[i] > pivot:
+			a[i], a[gt] = a[gt], a[i]
+			gt--
+		default:
+			i++
+		}
+	}
+	return lt, gt
+}
+
+// minFloat returns the smallest value of a non-empty slice.
+func minFloat(a []float64) float64 {
+	m := a[0]
+	for _, v := range a[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
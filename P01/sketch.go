@@ -0,0 +1,306 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+)
+
+const (
+	DefaultSketchK = 200
+	minSketchK     = 8
+	sketchVersion  = 1
+)
+
+// QuantileSketch is a KLL quantile sketch (Karnin, Lang, Liberty 2016).
+// It answers approximate quantile queries over an unbounded stream in
+// O(k log(n/k)) memory, with a rank error of roughly 1.7/k for k=200.
+// Sketches built on different workers can be merged. The zero value is ready
+// to use with DefaultSketchK, or takes the k of the first sketch merged in.
+type QuantileSketch struct {
+	k        int
+	levels   [][]float64 // levels[h] holds items of weight 2^h
+	size     int         // items currently retained across all levels
+	maxSize  int
+	n        uint64 // items ever added
+	min, max float64
+	rng      uint64 // xorshift state for the compaction coin flips
+}
+
+// NewQuantileSketch returns an empty sketch; k<=0 selects DefaultSketchK.
+// Larger k means better accuracy and more memory.
+func NewQuantileSketch(k int) *QuantileSketch {
+	if k <= 0 {
+		k = DefaultSketchK
+	}
+	if k < minSketchK {
+		k = minSketchK
+	}
+	s := &QuantileSketch{}
+	s.init(k)
+	return s
+}
+
+// init sets up a zero sketch with k; it does nothing once s has levels.
+func (s *QuantileSketch) init(k int) {
+	if len(s.levels) > 0 {
+		return
+	}
+	s.k, s.rng = k, 0x9E3779B97F4A7C15
+	s.grow()
+}
+
+func (s *QuantileSketch) Count() uint64 { return s.n }
+
+func (s *QuantileSketch) Add(x float64) {
+	s.init(DefaultSketchK)
+	if s.n == 0 {
+		s.min, s.max = x, x
+	} else {
+		s.min = math.Min(s.min, x)
+		s.max = math.Max(s.max, x)
+	}
+	s.n++
+	s.levels[0] = append(s.levels[0], x)
+	s.size++
+	if s.size >= s.maxSize {
+		s.compress()
+	}
+}
+
+// Merge folds other into s. other is left unchanged.
+func (s *QuantileSketch) Merge(other *QuantileSketch) {
+	if other == nil || other.n == 0 {
+		return
+	}
+	s.init(other.k)
+	if s.n == 0 {
+		s.min, s.max = other.min, other.max
+	} else {
+		s.min = math.Min(s.min, other.min)
+		s.max = math.Max(s.max, other.max)
+	}
+	s.n += other.n
+	for len(s.levels) < len(other.levels) {
+		s.grow()
+	}
+	for h, items := range other.levels {
+		s.levels[h] = append(s.levels[h], items...)
+		s.size += len(items)
+	}
+	for s.size >= s.maxSize {
+		s.compress()
+	}
+}
+
+// Quantile returns an approximation of the q-th quantile (0..1).
+// q=0 and q=1 are exact (the observed min and max).
+func (s *QuantileSketch) Quantile(q float64) (float64, error) {
+	if s.n == 0 {
+		return 0, ErrEmptySlice
+	}
+	if err := requireQuantile(q); err != nil {
+		return 0, err
+	}
+	switch q {
+	case 0:
+		return s.min, nil
+	case 1:
+		return s.max, nil
+	}
+
+	type weighted struct {
+		v float64
+		w uint64
+	}
+	items := make([]weighted, 0, s.size)
+	for h, level := range s.levels {
+		for _, v := range level {
+			items = append(items, weighted{v, 1 << h})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].v < items[j].v })
+
+	target := q * float64(s.n)
+	var cum uint64
+	for _, it := range items {
+		cum += it.w
+		if float64(cum) >= target {
+			return it.v, nil
+		}
+	}
+	return s.max, nil
+}
+
+func (s *QuantileSketch) capacity(h int) int {
+	depth := len(s.levels) - h - 1
+	return int(math.Ceil(math.Pow(2.0/3.0, float64(depth))*float64(s.k))) + 1
+}
+
+func (s *QuantileSketch) grow() {
+	s.levels = append(s.levels, nil)
+	s.maxSize = 0
+	for h := range s.levels {
+		s.maxSize += s.capacity(h)
+	}
+}
+
+// compress compacts the lowest level that is over capacity: its items are
+// sorted and every other one is promoted to the next level with double weight.
+func (s *QuantileSketch) compress() {
+	for h := 0; h < len(s.levels); h++ {
+		if len(s.levels[h]) < s.capacity(h) {
+			continue
+		}
+		if h+1 >= len(s.levels) {
+			s.grow()
+		}
+		level := s.levels[h]
+		sort.Float64s(level)
+
+		// with an odd count the smallest item stays behind at this level
+		start := len(level) % 2
+		offset := int(s.coin())
+		for i := start; i+1 < len(level); i += 2 {
+			s.levels[h+1] = append(s.levels[h+1], level[i+offset])
+		}
+		s.levels[h] = append(level[:0], level[:start]...)
+
+		s.size = 0
+		for _, l := range s.levels {
+			s.size += len(l)
+		}
+		return
+	}
+}
+
+func (s *QuantileSketch) coin() uint64 {
+	s.rng ^= s.rng << 13
+	s.rng ^= s.rng >> 7
+	s.rng ^= s.rng << 17
+	return s.rng & 1
+}
+
+// MarshalBinary encodes the sketch, including its coin state, so that a
+// decoded sketch continues exactly where the original left off.
+func (s *QuantileSketch) MarshalBinary() ([]byte, error) {
+	s.init(DefaultSketchK)
+	buf := make([]byte, 0, 64+8*s.size)
+	buf = append(buf, sketchVersion)
+	buf = binary.AppendUvarint(buf, uint64(s.k))
+	buf = binary.AppendUvarint(buf, s.n)
+	buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(s.min))
+	buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(s.max))
+	buf = binary.LittleEndian.AppendUint64(buf, s.rng)
+	buf = binary.AppendUvarint(buf, uint64(len(s.levels)))
+	for _, level := range s.levels {
+		buf = binary.AppendUvarint(buf, uint64(len(level)))
+		for _, v := range level {
+			buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(v))
+		}
+	}
+	return buf, nil
+}
+
+func (s *QuantileSketch) UnmarshalBinary(data []byte) error {
+	r := sketchReader{buf: data}
+	if v := r.byte(); v != sketchVersion {
+		if r.err != nil {
+			return r.err
+		}
+		return fmt.Errorf("%w: unsupported version %d", ErrCorruptSketch, v)
+	}
+	k := r.uvarint()
+	n := r.uvarint()
+	minV := math.Float64frombits(r.uint64())
+	maxV := math.Float64frombits(r.uint64())
+	rng := r.uint64()
+	nLevels := r.uvarint()
+	if r.err != nil {
+		return r.err
+	}
+	if k < minSketchK || k > math.MaxInt32 || nLevels == 0 || nLevels > 64 || rng == 0 {
+		return fmt.Errorf("%w: invalid header", ErrCorruptSketch)
+	}
+
+	levels := make([][]float64, nLevels)
+	var weight uint64
+	for h := range levels {
+		cnt := r.uvarint()
+		if r.err != nil {
+			return r.err
+		}
+		if cnt > uint64(r.remaining()/8) {
+			return fmt.Errorf("%w: level %d overruns buffer", ErrCorruptSketch, h)
+		}
+		levels[h] = make([]float64, cnt)
+		for i := range levels[h] {
+			levels[h][i] = math.Float64frombits(r.uint64())
+		}
+		weight += cnt << h
+	}
+	if r.err != nil {
+		return r.err
+	}
+	if r.remaining() != 0 {
+		return fmt.Errorf("%w: %d trailing bytes", ErrCorruptSketch, r.remaining())
+	}
+	if weight != n {
+		return fmt.Errorf("%w: retained weight %d does not match count %d", ErrCorruptSketch, weight, n)
+	}
+
+	*s = QuantileSketch{k: int(k), n: n, min: minV, max: maxV, rng: rng}
+	for range levels {
+		s.grow()
+	}
+	s.levels = levels
+	for _, l := range levels {
+		s.size += len(l)
+	}
+	return nil
+}
+
+// sketchReader decodes the MarshalBinary layout, remembering the first error.
+type sketchReader struct {
+	buf []byte
+	err error
+}
+
+func (r *sketchReader) remaining() int { return len(r.buf) }
+
+func (r *sketchReader) fail() {
+	if r.err == nil {
+		r.err = fmt.Errorf("%w: truncated data", ErrCorruptSketch)
+	}
+}
+
+func (r *sketchReader) byte() byte {
+	if len(r.buf) < 1 {
+		r.fail()
+		return 0
+	}
+	b := r.buf[0]
+	r.buf = r.buf[1:]
+	return b
+}
+
+func (r *sketchReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.buf)
+	if n <= 0 {
+		r.fail()
+		return 0
+	}
+	r.buf = r.buf[n:]
+	return v
+}
+
+func (r *sketchReader) uint64() uint64 {
+	if len(r.buf) < 8 {
+		r.fail()
+		return 0
+	}
+	v := binary.LittleEndian.Uint64(r.buf)
+	r.buf = r.buf[8:]
+	return v
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// rankError reports how far (as a fraction of n) v's rank in sorted is from q.
+func rankError(sorted []float64, v, q float64) float64 {
+	lo := sort.SearchFloat64s(sorted, v)
+	hi := sort.Search(len(sorted), func(i int) bool { return sorted[i] > v })
+	n := float64(len(sorted))
+	target := q * n
+	switch {
+	case target < float64(lo):
+		return (float64(lo) - target) / n
+	case target > float64(hi):
+		return (target - float64(hi)) / n
+	}
+	return 0
+}
+
+func sketchTestData(n int, seed int64) []float64 {
+	rng := rand.New(rand.NewSource(seed))
+	xs := make([]float64, n)
+	for i := range xs {
+		xs[i] = rng.NormFloat64()*8 + 25
+	}
+	return xs
+}
+
+func TestQuantileSketchErrorBound(t *testing.T) {
+	const bound = 0.02 // rank error allowed for k=200
+	xs := sketchTestData(200_000, 7)
+	s := NewQuantileSketch(DefaultSketchK)
+	for _, v := range xs {
+		s.Add(v)
+	}
+	sorted := append([]float64(nil), xs...)
+	sort.Float64s(sorted)
+
+	for _, q := range []float64{0.01, 0.1, 0.25, 0.5, 0.75, 0.9, 0.95, 0.99} {
+		got, err := s.Quantile(q)
+		if err != nil {
+			t.Fatalf("q=%v err=%v", q, err)
+		}
+		exact, _ := Quantile(xs, q)
+		if e := rankError(sorted, got, q); e > bound {
+			t.Fatalf("q=%v got=%v exact=%v rank error=%v > %v", q, got, exact, e, bound)
+		}
+	}
+
+	med, _ := Median(xs)
+	got, _ := s.Quantile(0.5)
+	if e := rankError(sorted, got, 0.5); e > bound {
+		t.Fatalf("median got=%v exact=%v rank error=%v", got, med, e)
+	}
+	if lo, _ := s.Quantile(0); lo != sorted[0] {
+		t.Fatalf("min got=%v, want=%v", lo, sorted[0])
+	}
+	if hi, _ := s.Quantile(1); hi != sorted[len(sorted)-1] {
+		t.Fatalf("max got=%v, want=%v", hi, sorted[len(sorted)-1])
+	}
+}
+
+func TestQuantileSketchMerge(t *testing.T) {
+	xs := sketchTestData(100_000, 11)
+	parts := make([]*QuantileSketch, 4)
+	for i := range parts {
+		parts[i] = NewQuantileSketch(0)
+	}
+	for i, v := range xs {
+		parts[i%len(parts)].Add(v)
+	}
+	merged := NewQuantileSketch(0)
+	for _, p := range parts {
+		merged.Merge(p)
+	}
+	if merged.Count() != uint64(len(xs)) {
+		t.Fatalf("count got=%d, want=%d", merged.Count(), len(xs))
+	}
+
+	sorted := append([]float64(nil), xs...)
+	sort.Float64s(sorted)
+	for _, q := range []float64{0.05, 0.5, 0.9, 0.99} {
+		got, _ := merged.Quantile(q)
+		if e := rankError(sorted, got, q); e > 0.02 {
+			t.Fatalf("q=%v got=%v rank error=%v", q, got, e)
+		}
+	}
+}
+
+func TestQuantileSketchBinaryRoundTrip(t *testing.T) {
+	s := NewQuantileSketch(64)
+	for _, v := range sketchTestData(10_000, 3) {
+		s.Add(v)
+	}
+	data, err := s.MarshalBinary()
+	if err != nil {
+		t.Fatalf("marshal err=%v", err)
+	}
+	var back QuantileSketch
+	if err := back.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unmarshal err=%v", err)
+	}
+	if back.Count() != s.Count() {
+		t.Fatalf("count got=%d, want=%d", back.Count(), s.Count())
+	}
+	for _, q := range []float64{0, 0.1, 0.5, 0.9, 1} {
+		a, _ := s.Quantile(q)
+		b, _ := back.Quantile(q)
+		if a != b {
+			t.Fatalf("q=%v original=%v decoded=%v", q, a, b)
+		}
+	}
+
+	// the decoded sketch keeps working and stays in lockstep with the original
+	for _, v := range sketchTestData(5_000, 4) {
+		s.Add(v)
+		back.Add(v)
+	}
+	a, _ := s.Quantile(0.5)
+	b, _ := back.Quantile(0.5)
+	if a != b {
+		t.Fatalf("after more adds original=%v decoded=%v", a, b)
+	}
+}
+
+func TestQuantileSketchCorrupt(t *testing.T) {
+	s := NewQuantileSketch(0)
+	for i := 0; i < 1000; i++ {
+		s.Add(float64(i))
+	}
+	data, _ := s.MarshalBinary()
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"truncated", data[:len(data)-3]},
+		{"trailing", append(append([]byte(nil), data...), 0)},
+		{"version", append([]byte{99}, data[1:]...)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var back QuantileSketch
+			if err := back.UnmarshalBinary(tt.data); !errors.Is(err, ErrCorruptSketch) {
+				t.Fatalf("err=%v, want ErrCorruptSketch", err)
+			}
+		})
+	}
+}
+
+func TestQuantileSketchEmpty(t *testing.T) {
+	s := NewQuantileSketch(0)
+	if _, err := s.Quantile(0.5); !errors.Is(err, ErrEmptySlice) {
+		t.Fatalf("err=%v, want ErrEmptySlice", err)
+	}
+	s.Add(1)
+	if _, err := s.Quantile(2); !errors.Is(err, ErrInvalidQuantile) {
+		t.Fatalf("err=%v, want ErrInvalidQuantile", err)
+	}
+}
+
+func TestQuantileSketchZeroValue(t *testing.T) {
+	var s QuantileSketch
+	for i := 1; i <= 10000; i++ {
+		s.Add(float64(i))
+	}
+	if got, _ := s.Quantile(0.5); math.Abs(got-5000) > 200 {
+		t.Fatalf("median=%v, want ~5000", got)
+	}
+
+	var merged QuantileSketch
+	src := NewQuantileSketch(50)
+	src.Add(1)
+	merged.Merge(src)
+	if merged.k != 50 || merged.Count() != 1 {
+		t.Fatalf("k=%d count=%d, want the merged sketch's k=50 and count 1", merged.k, merged.Count())
+	}
+
+	var empty QuantileSketch
+	data, err := empty.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back QuantileSketch
+	if err := back.UnmarshalBinary(data); err != nil {
+		t.Fatalf("zero sketch does not round-trip: %v", err)
+	}
+}
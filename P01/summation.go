@@ -0,0 +1,32 @@
+package main
+
+import "math"
+
+// neumaier is a compensated running sum (Kahan–Babuška–Neumaier). It keeps
+// the low-order bits lost by each addition in c, so adding many small values
+// to a large one does not silently drop them.
+type neumaier struct {
+	sum float64
+	c   float64
+}
+
+func (k *neumaier) add(x float64) {
+	t := k.sum + x
+	if math.IsInf(t, 0) || math.IsNaN(t) {
+		k.sum = t // Inf-Inf in the compensation would turn overflow into NaN
+		return
+	}
+	if math.Abs(k.sum) >= math.Abs(x) {
+		k.c += (k.sum - t) + x
+	} else {
+		k.c += (x - t) + k.sum
+	}
+	k.sum = t
+}
+
+func (k *neumaier) value() float64 {
+	if math.IsInf(k.sum, 0) {
+		return k.sum
+	}
+	return k.sum + k.c
+}
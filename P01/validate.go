@@ -1,8 +1,144 @@
 package main
 
-func requireNonEmpty(xs []float64) error {
+import "math"
+
+func requireNonEmpty[T any](xs []T) error {
 	if len(xs) == 0 {
 		return ErrEmptySlice
 	}
 	return nil
 }
+
+func requireQuantile(q float64) error {
+	if !(q >= 0 && q <= 1) { // also rejects NaN
+		return ErrInvalidQuantile
+	}
+	return nil
+}
+
+func requireSameLength(xs, ys []float64) error {
+	if len(xs) != len(ys) {
+		return ErrLengthMismatch
+	}
+	return nil
+}
+
+// requireWeights checks ws and returns their total.
+func requireWeights(ws []float64) (float64, error) {
+	var total neumaier
+	for _, w := range ws {
+		if w < 0 {
+			return 0, ErrNegativeWeight
+		}
+		total.add(w)
+	}
+	if total.value() == 0 {
+		return 0, ErrZeroWeight
+	}
+	return total.value(), nil
+}
+
+func requireDOF(n, ddof int) error {
+	if ddof < 0 || n <= ddof {
+		return ErrTooFewValues
+	}
+	return nil
+}
+
+func requireFinite(xs []float64) error {
+	for i, v := range xs {
+		if !isFinite(v) {
+			return &NonFiniteError{Index: i, Value: v}
+		}
+	}
+	return nil
+}
+
+// prepare runs the shared validation path of the stats functions and applies
+// the non-finite policy. The returned slice may alias xs; callers must not
+// modify it.
+func prepare(xs []float64, o options) ([]float64, error) {
+	if err := requireNonEmpty(xs); err != nil {
+		return nil, err
+	}
+	switch o.nonFinite {
+	case PropagateNonFinite:
+		return xs, nil
+	case SkipNonFinite:
+		xs = skipNonFinite(xs)
+		if err := requireNonEmpty(xs); err != nil {
+			return nil, err
+		}
+		return xs, nil
+	default:
+		if err := requireFinite(xs); err != nil {
+			return nil, err
+		}
+		return xs, nil
+	}
+}
+
+// preparePair is prepare for paired samples: a pair is rejected or skipped
+// when either of its values is non-finite, so xs[i] and ys[i] stay aligned.
+func preparePair(xs, ys []float64, o options) ([]float64, []float64, error) {
+	if err := requireSameLength(xs, ys); err != nil {
+		return nil, nil, err
+	}
+	if err := requireNonEmpty(xs); err != nil {
+		return nil, nil, err
+	}
+	switch o.nonFinite {
+	case PropagateNonFinite:
+		return xs, ys, nil
+	case SkipNonFinite:
+		var fx, fy []float64
+		for i := range xs {
+			if isFinite(xs[i]) && isFinite(ys[i]) {
+				fx = append(fx, xs[i])
+				fy = append(fy, ys[i])
+			}
+		}
+		if err := requireNonEmpty(fx); err != nil {
+			return nil, nil, err
+		}
+		return fx, fy, nil
+	default:
+		if err := requireFinite(xs); err != nil {
+			return nil, nil, err
+		}
+		if err := requireFinite(ys); err != nil {
+			return nil, nil, err
+		}
+		return xs, ys, nil
+	}
+}
+
+// skipNonFinite returns xs without NaN and ±Inf, copying only when needed.
+func skipNonFinite(xs []float64) []float64 {
+	for i, v := range xs {
+		if isFinite(v) {
+			continue
+		}
+		out := append([]float64(nil), xs[:i]...)
+		for _, w := range xs[i+1:] {
+			if isFinite(w) {
+				out = append(out, w)
+			}
+		}
+		return out
+	}
+	return xs
+}
+
+func isFinite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
+func hasNaN(xs []float64) bool {
+	for _, v := range xs {
+		if math.IsNaN(v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+type statFunc func([]float64, ...Option) (float64, error)
+
+var policyStats = map[string]statFunc{
+	"sum":      Sum,
+	"mean":     Mean,
+	"median":   Median,
+	"variance": Variance,
+	"std":      Std,
+	"p90": func(xs []float64, opts ...Option) (float64, error) {
+		return Quantile(xs, 0.9, opts...)
+	},
+}
+
+func TestNonFiniteReject(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    []float64
+		index int
+	}{
+		{"nan", []float64{1, 2, math.NaN(), 4}, 2},
+		{"+inf", []float64{math.Inf(1), 2}, 0},
+		{"-inf", []float64{1, 2, 3, math.Inf(-1)}, 3},
+	}
+	for _, tt := range tests {
+		for name, f := range policyStats {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				_, err := f(tt.in) // reject is the default
+				if !errors.Is(err, ErrNonFinite) {
+					t.Fatalf("err=%v, want ErrNonFinite", err)
+				}
+				var nf *NonFiniteError
+				if !errors.As(err, &nf) {
+					t.Fatalf("err=%T, want *NonFiniteError", err)
+				}
+				if nf.Index != tt.index {
+					t.Fatalf("index got=%d, want=%d", nf.Index, tt.index)
+				}
+			})
+		}
+	}
+}
+
+func TestNonFiniteSkip(t *testing.T) {
+	dirty := []float64{1, math.NaN(), 2, math.Inf(1), 3, math.Inf(-1), 4}
+	clean := []float64{1, 2, 3, 4}
+	for name, f := range policyStats {
+		t.Run(name, func(t *testing.T) {
+			got, err := f(dirty, WithNonFinite(SkipNonFinite))
+			if err != nil {
+				t.Fatalf("err=%v", err)
+			}
+			want, _ := f(clean)
+			if got != want {
+				t.Fatalf("got=%v, want=%v", got, want)
+			}
+		})
+	}
+	if !math.IsNaN(dirty[1]) { // skipping must not modify the caller's slice
+		t.Fatalf("input mutated: %v", dirty)
+	}
+
+	_, err := Mean([]float64{math.NaN()}, WithNonFinite(SkipNonFinite))
+	if !errors.Is(err, ErrEmptySlice) {
+		t.Fatalf("all skipped: err=%v, want ErrEmptySlice", err)
+	}
+}
+
+func TestNonFinitePropagate(t *testing.T) {
+	in := []float64{1, math.NaN(), 3}
+	for name, f := range policyStats {
+		t.Run(name, func(t *testing.T) {
+			got, err := f(in, WithNonFinite(PropagateNonFinite))
+			if err != nil {
+				t.Fatalf("err=%v", err)
+			}
+			if !math.IsNaN(got) {
+				t.Fatalf("got=%v, want NaN", got)
+			}
+		})
+	}
+
+	ps, err := PercentilesWith(in, []float64{50, 90}, WithNonFinite(PropagateNonFinite))
+	if err != nil || !math.IsNaN(ps[0]) || !math.IsNaN(ps[1]) {
+		t.Fatalf("percentiles got=%v err=%v", ps, err)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+// WeightedMean returns sum(w*x) / sum(w), e.g. a volume-weighted average price.
+func WeightedMean[T Number](data []T, ws []float64, opts ...Option) (float64, error) {
+	xs, ws, total, err := prepareWeighted(floats(data), ws, buildOptions(opts))
+	if err != nil {
+		return 0, err
+	}
+	return weightedMean(xs, ws, total), nil
+}
+
+// WeightedVariance treats ws as frequency weights and divides by sum(w)-ddof.
+func WeightedVariance[T Number](data []T, ws []float64, opts ...Option) (float64, error) {
+	o := buildOptions(opts)
+	xs, ws, total, err := prepareWeighted(floats(data), ws, o)
+	if err != nil {
+		return 0, err
+	}
+	if o.ddof < 0 || total <= float64(o.ddof) {
+		return 0, ErrTooFewValues
+	}
+	m := weightedMean(xs, ws, total)
+	var acc neumaier
+	for i, v := range xs {
+		d := v - m
+		acc.add(ws[i] * d * d)
+	}
+	return acc.value() / (total - float64(o.ddof)), nil
+}
+
+func WeightedMedian[T Number](xs []T, ws []float64, opts ...Option) (float64, error) {
+	return WeightedQuantile(xs, ws, 0.5, opts...)
+}
+
+// WeightedQuantile inverts the weighted empirical CDF: it returns the smallest
+// value whose cumulative weight reaches q*sum(w), averaging with the next value
+// when the target falls exactly on a boundary. With equal weights the median
+// matches Median.
+func WeightedQuantile[T Number](data []T, ws []float64, q float64, opts ...Option) (float64, error) {
+	o := buildOptions(opts)
+	xs, ws, total, err := prepareWeighted(floats(data), ws, o)
+	if err != nil {
+		return 0, err
+	}
+	if err := requireQuantile(q); err != nil {
+		return 0, err
+	}
+	if o.nonFinite == PropagateNonFinite && hasNaN(xs) {
+		return math.NaN(), nil
+	}
+
+	idx := make([]int, 0, len(xs))
+	for i, w := range ws {
+		if w > 0 {
+			idx = append(idx, i)
+		}
+	}
+	sort.Slice(idx, func(a, b int) bool { return xs[idx[a]] < xs[idx[b]] })
+
+	target := q * total
+	var cum neumaier
+	for k, i := range idx {
+		cum.add(ws[i])
+		c := cum.value()
+		if c < target {
+			continue
+		}
+		if c == target && k+1 < len(idx) {
+			return (xs[i] + xs[idx[k+1]]) / 2, nil
+		}
+		return xs[i], nil
+	}
+	return xs[idx[len(idx)-1]], nil
+}
+
+func weightedMean(xs, ws []float64, total float64) float64 {
+	var acc neumaier
+	for i, v := range xs {
+		acc.add(ws[i] * v)
+	}
+	return acc.value() / total
+}
+
+// prepareWeighted validates values and weights together and returns the total weight.
+func prepareWeighted(xs, ws []float64, o options) ([]float64, []float64, float64, error) {
+	xs, ws, err := preparePair(xs, ws, o)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	total, err := requireWeights(ws)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	return xs, ws, total, nil
+}
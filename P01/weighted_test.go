@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestWeighted(t *testing.T) {
+	prices := []float64{100, 101, 99, 102}
+	volume := []float64{10, 30, 20, 40}
+	tests := []struct {
+		name string
+		f    func(xs, ws []float64, opts ...Option) (float64, error)
+		xs   []float64
+		ws   []float64
+		want float64
+		err  error
+	}{
+		{"vwap", WeightedMean, prices, volume, 100.9, nil},
+		{"variance", WeightedVariance, prices, volume, 1.29, nil},
+		{"median", WeightedMedian, prices, volume, 101, nil},
+		{"p90", func(xs, ws []float64, opts ...Option) (float64, error) {
+			return WeightedQuantile(xs, ws, 0.9, opts...)
+		}, prices, volume, 102, nil},
+		{"equal weights mean", WeightedMean, []float64{1, 2, 3, 4}, []float64{1, 1, 1, 1}, 2.5, nil},
+		{"equal weights median even", WeightedMedian, []float64{4, 1, 3, 2}, []float64{2, 2, 2, 2}, 2.5, nil},
+		{"equal weights median odd", WeightedMedian, []float64{9, 1, 5}, []float64{1, 1, 1}, 5, nil},
+		{"zero weight ignored", WeightedMedian, []float64{1, 50, 2, 3}, []float64{1, 0, 1, 1}, 2, nil},
+
+		{"negative weight", WeightedMean, prices, []float64{1, -1, 1, 1}, 0, ErrNegativeWeight},
+		{"zero total", WeightedMean, prices, []float64{0, 0, 0, 0}, 0, ErrZeroWeight},
+		{"length mismatch", WeightedVariance, prices, volume[:3], 0, ErrLengthMismatch},
+		{"empty", WeightedMedian, nil, nil, 0, ErrEmptySlice},
+		{"nan weight", WeightedMean, prices, []float64{1, math.NaN(), 1, 1}, 0, ErrNonFinite},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.f(tt.xs, tt.ws)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err=%v, want %v", err, tt.err)
+			}
+			if tt.err == nil && !almostEqual(got, tt.want, 1e-12) {
+				t.Fatalf("got=%v, want=%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWeightedVarianceMatchesRepeated(t *testing.T) {
+	// integer frequency weights must match the unweighted result on repeated values
+	xs := []float64{1, 2, 5}
+	ws := []float64{2, 1, 3}
+	repeated := []float64{1, 1, 2, 5, 5, 5}
+	for _, ddof := range []int{0, 1} {
+		got, err := WeightedVariance(xs, ws, WithDDOF(ddof))
+		if err != nil {
+			t.Fatalf("ddof=%d err=%v", ddof, err)
+		}
+		want, _ := Variance(repeated, WithDDOF(ddof))
+		if !almostEqual(got, want, 1e-12) {
+			t.Fatalf("ddof=%d got=%v, want=%v", ddof, got, want)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// errBadArchive wraps anything that stops an archive from being listed. The
+// archive itself is still counted at its on-disk size.
+var errBadArchive = errors.New("unreadable archive")
+
+// maxSpool caps the size of a nested zip copied to a temporary file; larger
+// ones are counted as plain entries rather than risk filling $TMPDIR. It is
+// a variable so tests can lower it.
+var maxSpool int64 = 1 << 30
+
+// ArchiveInfo describes what an archive would take once extracted.
+type ArchiveInfo struct {
+	Name         string        `json:"name,omitempty"` // path inside the parent; empty at top level
+	Format       string        `json:"format"`         // zip, tar or tar.gz
+	Entries      int           `json:"entries"`        // regular files directly inside
+	Uncompressed int64         `json:"uncompressed"`   // sum of their sizes
+	Ratio        float64       `json:"ratio"`          // Uncompressed / archive size; 0 for an empty archive file
+	Nested       []ArchiveInfo `json:"nested,omitempty"`
+}
+
+// archiveFormat recognizes archives by name; "" means not an archive.
+func archiveFormat(name string) string {
+	n := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(n, ".zip"):
+		return "zip"
+	case strings.HasSuffix(n, ".tar"):
+		return "tar"
+	case strings.HasSuffix(n, ".tar.gz"), strings.HasSuffix(n, ".tgz"):
+		return "tar.gz"
+	}
+	return ""
+}
+
+// inspectArchive lists the archive at p of the given size. Archives nested
+// inside it are opened too while depth allows; depth 1 only lists p itself.
+func inspectArchive(ctx context.Context, p string, size int64, depth int) (*ArchiveInfo, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := listArchive(ctx, archiveFormat(p), f, size, depth)
+	if err != nil {
+		if ctx.Err() == nil {
+			err = fmt.Errorf("%w: %v", errBadArchive, err)
+		}
+		return nil, err // a partial listing would understate the contents
+	}
+	return info, nil
+}
+
+// listArchive reads an archive of the given format from r. Zip needs
+// random access, so r must be an io.ReaderAt for it.
+func listArchive(ctx context.Context, format string, r io.Reader, size int64, depth int) (*ArchiveInfo, error) {
+	info := &ArchiveInfo{Format: format}
+	var err error
+	switch format {
+	case "zip":
+		ra, ok := r.(io.ReaderAt)
+		if !ok {
+			return nil, errors.New("zip needs random access")
+		}
+		err = listZip(ctx, info, ra, size, depth)
+	case "tar":
+		err = listTar(ctx, info, r, depth)
+	case "tar.gz":
+		var zr *gzip.Reader
+		if zr, err = gzip.NewReader(ctxReader{ctx, r}); err == nil {
+			err = listTar(ctx, info, zr, depth)
+			if err == nil {
+				// drain to the end so a truncated or corrupt stream fails its checksum
+				_, err = io.Copy(io.Discard, zr)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported archive format %q", format)
+	}
+	if size > 0 {
+		info.Ratio = float64(info.Uncompressed) / float64(size)
+	}
+	return info, err
+}
+
+func listZip(ctx context.Context, info *ArchiveInfo, ra io.ReaderAt, size int64, depth int) error {
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		return err
+	}
+	for _, zf := range zr.File {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if zf.FileInfo().IsDir() {
+			continue
+		}
+		info.Entries++
+		info.Uncompressed += int64(zf.UncompressedSize64)
+		if depth <= 1 || archiveFormat(zf.Name) == "" {
+			continue
+		}
+		rc, err := zf.Open()
+		if err != nil {
+			return fmt.Errorf("%s: %w", zf.Name, err)
+		}
+		err = addNested(ctx, info, zf.Name, rc, int64(zf.UncompressedSize64), depth-1)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func listTar(ctx context.Context, info *ArchiveInfo, r io.Reader, depth int) error {
+	tr := tar.NewReader(r)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		info.Entries++
+		info.Uncompressed += hdr.Size
+		if depth > 1 && archiveFormat(hdr.Name) != "" {
+			if err := addNested(ctx, info, hdr.Name, tr, hdr.Size, depth-1); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// addNested lists an archive stored as an entry of another one, whose size
+// is as declared by the parent. Tar streams are read in place; a nested zip
+// is spooled to a temporary file first because it cannot be read
+// sequentially, unless it is over maxSpool.
+func addNested(ctx context.Context, parent *ArchiveInfo, name string, r io.Reader, size int64, depth int) error {
+	format := archiveFormat(name)
+	r = ctxReader{ctx, r} // skipping entries reads through the data too
+	if format == "zip" {
+		if size > maxSpool {
+			return nil
+		}
+		tmp, err := os.CreateTemp("", "p02-nested-*.zip")
+		if err != nil {
+			return err
+		}
+		defer func() {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}()
+		if size, err = io.Copy(tmp, io.LimitReader(r, size)); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		r = tmp
+	}
+	nested, err := listArchive(ctx, format, r, size, depth)
+	if err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	nested.Name = name
+	parent.Nested = append(parent.Nested, *nested)
+	return nil
+}
+
+// ctxReader fails every read once ctx is done, so draining a stream or
+// skipping over a large entry stops within one read of cancellation, like
+// the chunked reads of getFileSize.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c ctxReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type archiveFile struct {
+	name string
+	data []byte
+}
+
+func makeTar(t *testing.T, files []archiveFile) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	_ = tw.WriteHeader(&tar.Header{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0o755})
+	for _, f := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: f.name, Size: int64(len(f.data)), Mode: 0o644, Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write(f.data)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func makeTarGz(t *testing.T, files []archiveFile) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(makeTar(t, files))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func makeZip(t *testing.T, files []archiveFile) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	zw.Create("dir/")
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(f.data)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func writeArchive(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	if err := os.WriteFile(p, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestInspectArchive(t *testing.T) {
+	dir := t.TempDir()
+	zeros := bytes.Repeat([]byte{0}, 10000)
+	plain := []archiveFile{{"a.txt", []byte("hello")}, {"dir/zeros", zeros}}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		format  string
+		entries int
+		size    int64
+	}{
+		{"x.tar", makeTar(t, plain), "tar", 2, 10005},
+		{"x.tar.gz", makeTarGz(t, plain), "tar.gz", 2, 10005},
+		{"x.tgz", makeTarGz(t, plain), "tar.gz", 2, 10005},
+		{"X.ZIP", makeZip(t, plain), "zip", 2, 10005},
+		{"empty.zip", makeZip(t, nil), "zip", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := writeArchive(t, dir, tt.name, tt.data)
+			info, err := inspectArchive(context.Background(), p, int64(len(tt.data)), 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Format != tt.format || info.Entries != tt.entries || info.Uncompressed != tt.size {
+				t.Fatalf("got=%+v", info)
+			}
+			if want := float64(tt.size) / float64(len(tt.data)); info.Ratio != want {
+				t.Fatalf("ratio got=%v, want=%v", info.Ratio, want)
+			}
+		})
+	}
+}
+
+func TestInspectArchiveNested(t *testing.T) {
+	dir := t.TempDir()
+	inner := makeZip(t, []archiveFile{{"deep.txt", []byte("deep")}})
+	middle := makeTarGz(t, []archiveFile{{"inner.zip", inner}, {"m.txt", []byte("mm")}})
+	outer := makeZip(t, []archiveFile{{"middle.tar.gz", middle}, {"o.txt", []byte("o")}})
+	p := writeArchive(t, dir, "outer.zip", outer)
+
+	info, err := inspectArchive(context.Background(), p, int64(len(outer)), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Entries != 2 || len(info.Nested) != 1 {
+		t.Fatalf("outer got=%+v", info)
+	}
+	mid := info.Nested[0]
+	if mid.Name != "middle.tar.gz" || mid.Format != "tar.gz" || mid.Entries != 2 || len(mid.Nested) != 1 {
+		t.Fatalf("middle got=%+v", mid)
+	}
+	if in := mid.Nested[0]; in.Name != "inner.zip" || in.Entries != 1 || in.Uncompressed != 4 {
+		t.Fatalf("inner got=%+v", in)
+	}
+
+	// the depth limit leaves deeper archives as plain entries
+	info, err = inspectArchive(context.Background(), p, int64(len(outer)), 2)
+	if err != nil || len(info.Nested) != 1 || len(info.Nested[0].Nested) != 0 {
+		t.Fatalf("depth 2: info=%+v err=%v", info, err)
+	}
+	info, err = inspectArchive(context.Background(), p, int64(len(outer)), 1)
+	if err != nil || len(info.Nested) != 0 {
+		t.Fatalf("depth 1: info=%+v err=%v", info, err)
+	}
+}
+
+func TestScanFilesCorruptArchive(t *testing.T) {
+	dir := t.TempDir()
+	good := makeTarGz(t, []archiveFile{{"a", []byte("aaaa")}})
+	truncated := good[:len(good)-10]
+	files := []string{
+		writeArchive(t, dir, "good.tar.gz", good),
+		writeArchive(t, dir, "bad.tar.gz", truncated),
+		writeArchive(t, dir, "bad.zip", []byte("not a zip at all")),
+		writeArchive(t, dir, "nested.zip", makeZip(t, []archiveFile{{"in.tar.gz", truncated}})),
+	}
+
+	res := scanFiles(context.Background(), files, scanConfig{workers: 2, strategy: statSize, archiveDepth: 2})
+	if res.Files != 4 || res.TotalSize != int64(len(good)+len(truncated)+16)+res.Entries[3].Size {
+		t.Fatalf("corrupt archives must still be counted: %+v", res)
+	}
+	if len(res.Errors) != 3 {
+		t.Fatalf("errors=%v, want 3", res.Errors)
+	}
+	for _, e := range res.Errors {
+		if !errors.Is(e, errBadArchive) || strings.Contains(e.Path, "good") {
+			t.Fatalf("unexpected error %v", e)
+		}
+	}
+	for _, e := range res.Entries {
+		if got := e.Archive != nil; got != strings.Contains(e.Path, "good") {
+			t.Fatalf("%s: archive=%+v", e.Path, e.Archive)
+		}
+	}
+	if !strings.Contains(res.Errors[2].Error(), "in.tar.gz") {
+		t.Fatalf("nested error should name the inner archive: %v", res.Errors[2])
+	}
+
+	res = scanFiles(context.Background(), files, scanConfig{workers: 2, strategy: statSize})
+	if len(res.Errors) != 0 || res.Entries[0].Archive != nil {
+		t.Fatalf("archives must be opaque when disabled: %+v", res)
+	}
+}
+
+// cancelingReader cancels its context after a number of reads, standing in
+// for Ctrl-C in the middle of a large archive.
+type cancelingReader struct {
+	r      *bytes.Reader
+	reads  int
+	cancel context.CancelFunc
+}
+
+func (c *cancelingReader) Read(p []byte) (int, error) {
+	if c.reads--; c.reads == 0 {
+		c.cancel()
+	}
+	return c.r.Read(p)
+}
+
+func TestListArchiveCancel(t *testing.T) {
+	noise := make([]byte, 4<<20) // random, so compression leaves it large
+	rand.New(rand.NewSource(1)).Read(noise)
+	big := []archiveFile{{"noise.bin", noise}, {"after.txt", []byte("x")}}
+
+	tests := []struct {
+		name   string
+		format string
+		data   []byte
+		depth  int
+	}{
+		{"tar.gz skip", "tar.gz", makeTarGz(t, big), 1},
+		{"nested zip spool", "tar", makeTar(t, []archiveFile{{"inner.zip", makeZip(t, big)}}), 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			r := &cancelingReader{r: bytes.NewReader(tt.data), reads: 3, cancel: cancel}
+			_, err := listArchive(ctx, tt.format, struct{ io.Reader }{r}, int64(len(tt.data)), tt.depth)
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("err=%v, want context.Canceled", err)
+			}
+			if r.r.Len() < len(tt.data)/2 {
+				t.Fatalf("kept reading after cancel: %d of %d bytes left", r.r.Len(), len(tt.data))
+			}
+		})
+	}
+}
+
+func TestInspectArchiveSpoolLimit(t *testing.T) {
+	defer func(n int64) { maxSpool = n }(maxSpool)
+	maxSpool = 100
+
+	inner := makeZip(t, []archiveFile{{"a", bytes.Repeat([]byte("a"), 1000)}})
+	outer := makeZip(t, []archiveFile{{"inner.zip", inner}})
+	p := writeArchive(t, t.TempDir(), "outer.zip", outer)
+
+	info, err := inspectArchive(context.Background(), p, int64(len(outer)), 2)
+	if err != nil || info.Entries != 1 || len(info.Nested) != 0 {
+		t.Fatalf("a nested zip over the spool limit should stay a plain entry: info=%+v err=%v", info, err)
+	}
+}
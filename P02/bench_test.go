@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"testing"
+)
+
+var benchFixtures = []struct {
+	name  string
+	files int
+	size  int
+}{
+	{"1000x4KiB", 1000, 4 << 10},
+	{"64x1MiB", 64, 1 << 20},
+	{"4x64MiB", 4, 64 << 20},
+}
+
+// BenchmarkScan compares the stat and read strategies on the same generated
+// files. Read numbers on a warm page cache are a best case; on cold or
+// network storage the gap is much wider.
+func BenchmarkScan(b *testing.B) {
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(io.Discard)
+
+	for _, fx := range benchFixtures {
+		dir := b.TempDir()
+		sizes := make(map[string]int, fx.files)
+		for i := 0; i < fx.files; i++ {
+			sizes[fmt.Sprintf("f%04d.bin", i)] = fx.size
+		}
+		files := writeFiles(b, dir, sizes)
+
+		for _, strategy := range []sizeStrategy{statSize, readSize} {
+			b.Run(fx.name+"/"+string(strategy), func(b *testing.B) {
+				b.SetBytes(int64(fx.files * fx.size))
+				cfg := scanConfig{workers: 4, strategy: strategy}
+				for i := 0; i < b.N; i++ {
+					scanFiles(context.Background(), files, cfg)
+				}
+			})
+		}
+	}
+}
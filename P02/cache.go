@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// cacheVersion changes whenever cacheEntry changes meaning; a cache written
+// by another version is discarded rather than misread.
+const cacheVersion = 2
+
+var (
+	errCacheCorrupt = errors.New("scan cache is corrupt")
+	errCacheVersion = errors.New("scan cache has a different version")
+)
+
+// cacheFile is the on-disk JSON layout.
+type cacheFile struct {
+	Version int                   `json:"version"`
+	Hash    hashAlgo              `json:"hash,omitempty"` // algorithm behind every entry's Hash
+	Entries map[string]cacheEntry `json:"entries"`        // keyed by path as scanned
+}
+
+// cacheEntry is a measured file plus what identifies that version of it.
+type cacheEntry struct {
+	Size      int64        `json:"size"`
+	ModTime   int64        `json:"mtime"` // UnixNano
+	Inode     uint64       `json:"inode,omitempty"`
+	Strategy  sizeStrategy `json:"strategy"` // Allocated is only known when it stats
+	Allocated int64        `json:"allocated,omitempty"`
+	Hash      string       `json:"hash,omitempty"`
+	Depth     int          `json:"archive_depth,omitempty"` // archive depth Archive was listed with
+	Archive   *ArchiveInfo `json:"archive,omitempty"`
+}
+
+// scanCache lets a rerun trust earlier results for files whose size, mtime
+// and inode have not changed. It is safe for concurrent use by the workers.
+type scanCache struct {
+	path   string
+	verify bool // measure every file anyway and count entries that were wrong
+
+	mu      sync.Mutex // guards the fields below
+	hash    hashAlgo
+	entries map[string]cacheEntry
+	hits    int
+	misses  int
+	stale   int // verify found a cached entry that no longer matched
+}
+
+// loadCache reads the cache at path. A missing file starts an empty cache.
+// A corrupt file or one from another version also starts empty, and the
+// returned error says why so the caller can warn; the cache is still usable.
+func loadCache(path string, verify bool) (*scanCache, error) {
+	c := &scanCache{path: path, verify: verify, entries: map[string]cacheEntry{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return c, err
+	}
+
+	var f cacheFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return c, fmt.Errorf("%w: %v", errCacheCorrupt, err)
+	}
+	if f.Version != cacheVersion {
+		return c, fmt.Errorf("%w: got %d, want %d", errCacheVersion, f.Version, cacheVersion)
+	}
+	if f.Entries != nil {
+		c.entries = f.Entries
+	}
+	c.hash = f.Hash
+	return c, nil
+}
+
+// lookup returns the cached entry for path when the file is unchanged and
+// the entry holds everything cfg asks for. The stat result is returned for
+// store either way.
+func (c *scanCache) lookup(path string, cfg scanConfig) (FileEntry, os.FileInfo, bool) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return FileEntry{}, nil, false
+	}
+	c.mu.Lock()
+	ce, ok := c.entries[path]
+	hashOK := cfg.hash == noHash || (c.hash == cfg.hash && ce.Hash != "")
+	c.mu.Unlock()
+
+	ok = ok && hashOK &&
+		ce.Size == fi.Size() &&
+		ce.ModTime == fi.ModTime().UnixNano() &&
+		ce.Inode == inode(fi)
+	if ok && cfg.strategy.stats() {
+		ok = ce.Strategy.stats()
+	}
+	if ok && cfg.archiveDepth > 0 && archiveFormat(path) != "" {
+		ok = ce.Archive != nil && ce.Depth == cfg.archiveDepth
+	}
+	if !ok {
+		return FileEntry{}, fi, false
+	}
+	// hand back only what an uncached scan with cfg would have filled in
+	e := FileEntry{Path: path, Size: ce.Size}
+	if cfg.strategy.stats() {
+		e.Allocated = ce.Allocated
+	}
+	if cfg.hash != noHash {
+		e.Hash = ce.Hash
+	}
+	if cfg.archiveDepth > 0 {
+		e.Archive = ce.Archive
+	}
+	return e, fi, true
+}
+
+// store records a freshly measured entry. fi is the stat taken before
+// measuring, so a file modified meanwhile is re-measured next time.
+func (c *scanCache) store(e FileEntry, fi os.FileInfo, cfg scanConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cfg.hash != noHash && cfg.hash != c.hash {
+		// entries hashed with another algorithm keep their sizes but lose their hashes
+		for p, ce := range c.entries {
+			ce.Hash = ""
+			c.entries[p] = ce
+		}
+		c.hash = cfg.hash
+	}
+	ce := cacheEntry{
+		Size:      e.Size,
+		ModTime:   fi.ModTime().UnixNano(),
+		Inode:     inode(fi),
+		Strategy:  cfg.strategy,
+		Allocated: e.Allocated,
+		Hash:      e.Hash,
+		Archive:   e.Archive,
+	}
+	if e.Archive != nil {
+		ce.Depth = cfg.archiveDepth
+	}
+	c.entries[e.Path] = ce
+}
+
+func (c *scanCache) count(hit, stale bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case stale:
+		c.stale++
+	case hit:
+		c.hits++
+	default:
+		c.misses++
+	}
+}
+
+// prune drops entries under root that the last scan did not see, so deleted
+// files do not pile up. Entries for other roots are kept.
+func (c *scanCache) prune(root string, seen []FileEntry) {
+	keep := make(map[string]bool, len(seen))
+	for _, e := range seen {
+		keep[e.Path] = true
+	}
+	root = filepath.Clean(root)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for p := range c.entries {
+		if !keep[p] && (p == root || isAncestor(root, filepath.Dir(p))) {
+			delete(c.entries, p)
+		}
+	}
+}
+
+// save writes the cache next to its final path and renames it into place,
+// so an interrupted save never leaves a truncated cache behind.
+func (c *scanCache) save() error {
+	c.mu.Lock()
+	data, err := json.Marshal(cacheFile{Version: cacheVersion, Hash: c.hash, Entries: c.entries})
+	n := len(c.entries)
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op after a successful rename
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	log.Printf("[cache] saved %d entries to %s", n, c.path)
+	return os.Rename(tmp.Name(), c.path)
+}
+
+// Stats reports how the cache was used by the scans so far.
+func (c *scanCache) Stats() (hits, misses, stale int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses, c.stale
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadCacheErrors(t *testing.T) {
+	dir := t.TempDir()
+	c, err := loadCache(filepath.Join(dir, "missing.json"), false)
+	if err != nil || len(c.entries) != 0 {
+		t.Fatalf("missing file: c=%+v err=%v", c, err)
+	}
+
+	tests := []struct {
+		name string
+		data string
+		want error
+	}{
+		{"corrupt", `{"version":1,"entries":{`, errCacheCorrupt},
+		{"not json", "\x00\x01binary", errCacheCorrupt},
+		{"old version", `{"version":0,"entries":{"a":{"size":1}}}`, errCacheVersion},
+		{"new version", `{"version":99}`, errCacheVersion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := filepath.Join(dir, tt.name+".json")
+			if err := os.WriteFile(p, []byte(tt.data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			c, err := loadCache(p, false)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("err=%v, want %v", err, tt.want)
+			}
+			if c == nil || len(c.entries) != 0 {
+				t.Fatalf("a rejected cache must still come back empty and usable: %+v", c)
+			}
+			if err := c.save(); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := loadCache(p, false); err != nil {
+				t.Fatalf("rewritten cache does not load: %v", err)
+			}
+		})
+	}
+}
+
+func TestScanCache(t *testing.T) {
+	dir := t.TempDir()
+	files := writeFiles(t, dir, map[string]int{"a": 100, "b": 200})
+	cachePath := filepath.Join(t.TempDir(), "cache.json")
+	ctx := context.Background()
+
+	scan := func(verify bool, hash hashAlgo) (scanResult, *scanCache) {
+		t.Helper()
+		c, err := loadCache(cachePath, verify)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res := scanFiles(ctx, files, scanConfig{workers: 2, strategy: readSize, hash: hash, cache: c})
+		if err := c.save(); err != nil {
+			t.Fatal(err)
+		}
+		return res, c
+	}
+
+	res, c := scan(false, sha256H)
+	if hits, misses, _ := c.Stats(); hits != 0 || misses != 2 || res.TotalSize != 300 {
+		t.Fatalf("cold run: hits=%d misses=%d res=%+v", hits, misses, res)
+	}
+	firstHash := res.Entries[0].Hash
+
+	// rewrite a with different bytes but the same size and mtime: a trusting
+	// rerun keeps the cached hash, verification notices
+	a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b") // files is in map order
+	fi, _ := os.Stat(a)
+	if err := os.WriteFile(a, []byte(string(make([]byte, 99))+"x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(a, fi.ModTime(), fi.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	res, c = scan(false, sha256H)
+	if hits, misses, _ := c.Stats(); hits != 2 || misses != 0 || res.Entries[0].Hash != firstHash {
+		t.Fatalf("warm run: hits=%d misses=%d entries=%+v", hits, misses, res.Entries)
+	}
+	res, c = scan(true, sha256H)
+	if _, _, stale := c.Stats(); stale != 1 || res.Entries[0].Hash == firstHash {
+		t.Fatalf("verify run: stale=%d entries=%+v", stale, res.Entries)
+	}
+
+	// a newer mtime or another hash algorithm is a miss
+	later := fi.ModTime().Add(time.Minute)
+	if err := os.Chtimes(b, later, later); err != nil {
+		t.Fatal(err)
+	}
+	_, c = scan(false, sha256H)
+	if hits, misses, _ := c.Stats(); hits != 1 || misses != 1 {
+		t.Fatalf("after touch: hits=%d misses=%d", hits, misses)
+	}
+	_, c = scan(false, blake2H)
+	if hits, misses, _ := c.Stats(); hits != 0 || misses != 2 {
+		t.Fatalf("other hash: hits=%d misses=%d", hits, misses)
+	}
+	_, c = scan(false, noHash)
+	if hits, _, _ := c.Stats(); hits != 2 {
+		t.Fatalf("sizes only: hits=%d", hits)
+	}
+}
+
+func TestScanCachePrune(t *testing.T) {
+	c := &scanCache{entries: map[string]cacheEntry{
+		filepath.FromSlash("root/a"):     {},
+		filepath.FromSlash("root/sub/b"): {},
+		filepath.FromSlash("other/c"):    {},
+		filepath.FromSlash("rootless/d"): {},
+	}}
+	c.prune("root", []FileEntry{{Path: filepath.FromSlash("root/a")}})
+	if len(c.entries) != 3 {
+		t.Fatalf("entries=%v, want root/sub/b dropped only", c.entries)
+	}
+	if _, ok := c.entries[filepath.FromSlash("root/sub/b")]; ok {
+		t.Fatal("unseen entry under root was kept")
+	}
+}
+
+func TestScanCacheMatchesUncachedScan(t *testing.T) {
+	dir := t.TempDir()
+	files := append(writeFiles(t, dir, map[string]int{"plain": 5000}),
+		writeArchive(t, dir, "a.tar", makeTar(t, []archiveFile{{"x", []byte("xyz")}})))
+	fi, err := os.Stat(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, haveAllocated := allocatedSize(fi)
+	ctx := context.Background()
+
+	scan := func(cachePath string, cfg scanConfig) (scanResult, *scanCache) {
+		t.Helper()
+		c, err := loadCache(cachePath, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg.workers, cfg.cache = 2, c
+		res := scanFiles(ctx, files, cfg)
+		if err := c.save(); err != nil {
+			t.Fatal(err)
+		}
+		return res, c
+	}
+
+	// entries measured by reading carry no allocated size, so a stat run
+	// must not trust them
+	readFirst := filepath.Join(t.TempDir(), "cache.json")
+	scan(readFirst, scanConfig{strategy: readSize})
+	res, c := scan(readFirst, scanConfig{strategy: statSize})
+	if hits, misses, _ := c.Stats(); hits != 0 || misses != 2 {
+		t.Fatalf("stat after read: hits=%d misses=%d", hits, misses)
+	}
+	if haveAllocated && res.Allocated == 0 {
+		t.Fatalf("stat after read lost the allocated size: %+v", res)
+	}
+
+	// cached archive listings and allocated sizes only come back when the
+	// current run asks for them
+	statFirst := filepath.Join(t.TempDir(), "cache.json")
+	scan(statFirst, scanConfig{strategy: statSize, archiveDepth: 2})
+	res, c = scan(statFirst, scanConfig{strategy: statSize})
+	if hits, _, _ := c.Stats(); hits != 2 {
+		t.Fatalf("stat rerun: hits=%d", hits)
+	}
+	for _, e := range res.Entries {
+		if e.Archive != nil {
+			t.Fatalf("archive listed without -archives: %+v", e)
+		}
+	}
+	res, c = scan(statFirst, scanConfig{strategy: readSize})
+	if hits, _, _ := c.Stats(); hits != 2 || res.Allocated != 0 {
+		t.Fatalf("read rerun: hits=%d allocated=%d, want 2 hits and no allocated size", hits, res.Allocated)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"sort"
+
+	"github.com/cespare/xxhash/v2"
+	"golang.org/x/crypto/blake2b"
+)
+
+// hashAlgo names the content hash computed while files are read.
+type hashAlgo string
+
+const (
+	noHash  hashAlgo = ""
+	sha256H hashAlgo = "sha256"
+	xxhashH hashAlgo = "xxhash"  // fastest; fine for dedup, not collision resistant
+	blake2H hashAlgo = "blake2b" // 256-bit, faster than SHA-256 on most CPUs
+)
+
+func (a hashAlgo) valid() bool {
+	switch a {
+	case noHash, sha256H, xxhashH, blake2H:
+		return true
+	}
+	return false
+}
+
+// newHash returns a fresh hasher for a, or nil for noHash.
+func (a hashAlgo) newHash() hash.Hash {
+	switch a {
+	case sha256H:
+		return sha256.New()
+	case xxhashH:
+		return xxhash.New()
+	case blake2H:
+		h, _ := blake2b.New256(nil) // only fails for keys longer than 64 bytes
+		return h
+	}
+	return nil
+}
+
+// DuplicateGroup is a set of files with identical size and content hash.
+type DuplicateGroup struct {
+	Size   int64    `json:"size"`
+	Hash   string   `json:"hash"`
+	Paths  []string `json:"paths"`  // sorted
+	Wasted int64    `json:"wasted"` // bytes taken by every copy but one
+}
+
+// findDuplicates groups entries by size, then by hash within each size, so
+// files with a unique size never have their hashes compared. Empty files
+// and entries without a hash are ignored. Groups come back with the most
+// wasted bytes first.
+func findDuplicates(entries []FileEntry) []DuplicateGroup {
+	bySize := make(map[int64][]FileEntry)
+	for _, e := range entries {
+		if e.Size > 0 && e.Hash != "" {
+			bySize[e.Size] = append(bySize[e.Size], e)
+		}
+	}
+
+	var groups []DuplicateGroup
+	for size, same := range bySize {
+		if len(same) < 2 {
+			continue
+		}
+		byHash := make(map[string][]string)
+		for _, e := range same {
+			byHash[e.Hash] = append(byHash[e.Hash], e.Path)
+		}
+		for h, paths := range byHash {
+			if len(paths) < 2 {
+				continue
+			}
+			sort.Strings(paths)
+			groups = append(groups, DuplicateGroup{
+				Size:   size,
+				Hash:   h,
+				Paths:  paths,
+				Wasted: size * int64(len(paths)-1),
+			})
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Wasted != groups[j].Wasted {
+			return groups[i].Wasted > groups[j].Wasted
+		}
+		return groups[i].Paths[0] < groups[j].Paths[0]
+	})
+	return groups
+}
+
+func hexSum(h hash.Hash) string { return hex.EncodeToString(h.Sum(nil)) }
+
+func writeDuplicates(w io.Writer, groups []DuplicateGroup, algo hashAlgo) {
+	var files int
+	var wasted int64
+	for _, g := range groups {
+		files += len(g.Paths) - 1
+		wasted += g.Wasted
+	}
+	fmt.Fprintf(w, "Duplicates: %d groups, %d redundant files, %s wasted\n", len(groups), files, humanBytes(wasted))
+	for _, g := range groups {
+		fmt.Fprintf(w, "  %s x%d (wasted %s) %s:%s\n", humanBytes(g.Size), len(g.Paths), humanBytes(g.Wasted), algo, g.Hash)
+		for _, p := range g.Paths {
+			fmt.Fprintf(w, "    %s\n", p)
+		}
+	}
+}
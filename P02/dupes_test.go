@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestHashAlgos(t *testing.T) {
+	// digests of "abc"
+	tests := map[hashAlgo]string{
+		sha256H: "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+		xxhashH: "44bc2cf5ad770999",
+		blake2H: "bddd813c634239723171ef3fee98579b94964e3bb1cb3e427262c8c068d52319",
+	}
+	for algo, want := range tests {
+		t.Run(string(algo), func(t *testing.T) {
+			h := algo.newHash()
+			h.Write([]byte("abc"))
+			if got := hexSum(h); got != want {
+				t.Fatalf("got=%s, want=%s", got, want)
+			}
+		})
+	}
+	if noHash.newHash() != nil || !noHash.valid() || hashAlgo("md5").valid() {
+		t.Fatal("noHash must be valid with a nil hasher; unknown names invalid")
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	entries := []FileEntry{
+		{Path: "b", Size: 10, Hash: "x"},
+		{Path: "a", Size: 10, Hash: "x"},
+		{Path: "c", Size: 10, Hash: "y"}, // same size, other content
+		{Path: "d", Size: 3, Hash: "z"},
+		{Path: "e", Size: 3, Hash: "z"},
+		{Path: "f", Size: 3, Hash: "z"},
+		{Path: "g", Size: 7, Hash: "x"}, // same hash, unique size
+		{Path: "h", Size: 0, Hash: "e"},
+		{Path: "i", Size: 0, Hash: "e"}, // empty files are not reported
+		{Path: "j", Size: 5},
+		{Path: "k", Size: 5}, // no hash
+	}
+	want := []DuplicateGroup{
+		{Size: 10, Hash: "x", Paths: []string{"a", "b"}, Wasted: 10},
+		{Size: 3, Hash: "z", Paths: []string{"d", "e", "f"}, Wasted: 6},
+	}
+	if got := findDuplicates(entries); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got=%+v, want=%+v", got, want)
+	}
+}
+
+func TestScanFilesFindsDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	content := map[string]string{
+		"a.bin":     "same bytes",
+		"sub/b.bin": "same bytes",
+		"c.bin":     "diff bytes", // same size as a and b
+		"d.bin":     "unique",
+	}
+	var files []string
+	for name, data := range content {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, p)
+	}
+
+	for _, algo := range []hashAlgo{sha256H, xxhashH, blake2H} {
+		res := scanFiles(context.Background(), files, scanConfig{workers: 2, hash: algo})
+		groups := findDuplicates(res.Entries)
+		want := []string{filepath.Join(dir, "a.bin"), filepath.Join(dir, "sub/b.bin")}
+		if len(groups) != 1 || !reflect.DeepEqual(groups[0].Paths, want) || groups[0].Wasted != 10 {
+			t.Fatalf("%s: groups=%+v", algo, groups)
+		}
+	}
+
+	res := scanFiles(context.Background(), files, scanConfig{workers: 2})
+	if len(res.Entries) != len(files) || res.Entries[0].Hash != "" {
+		t.Fatalf("without hashing got entries=%+v", res.Entries)
+	}
+}
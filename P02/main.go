@@ -1,97 +1,238 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"log"
 	"os"
-	"path/filepath"
-	"sync"
-	"sync/atomic"
+	"os/signal"
+	"runtime"
+	"syscall"
 	"time"
 )
 
-func main() {
-	log.SetFlags(log.Ltime | log.Lmicroseconds)
-
-	dir := "P02/tmp/files/"
-	log.Printf("[main] start. scanning dir=%q", dir)
+// Exit codes.
+const (
+	exitOK         = 0
+	exitFileErrors = 1 // some files could not be measured
+	exitUsage      = 2
+	exitIncomplete = 3 // canceled or timed out; the total is partial
+)
 
-	files, err := filepath.Glob(filepath.Join(dir, "*"))
-	if err != nil {
-		log.Fatalf("[main] glob error: %v", err)
-	}
-	if len(files) == 0 {
-		log.Printf("[main] no files found in %q (nothing to do)", dir)
-		return
-	}
-	log.Printf("[main] found %d files", len(files))
+type cliConfig struct {
+	dir      string
+	walk     walkConfig
+	scan     scanConfig
+	dupes    bool
+	out      string // text, json, csv or tree
+	top      int
+	timeout  time.Duration
+	progress bool // redraw a progress line on stderr during run
+
+	watch    bool
+	interval time.Duration // between watch polls
+	debounce time.Duration // how long a file must be left alone before a watch re-reads it
+}
 
+func main() {
 	var (
-		totalSize int64
-		mu        sync.Mutex
-		wg        sync.WaitGroup
-		nextID    int64
+		include, exclude patternList
+
+		dir         = flag.String("dir", "P02/tmp/files", "directory to scan recursively")
+		workers     = flag.Int("workers", runtime.GOMAXPROCS(0), "max files read concurrently")
+		maxDepth    = flag.Int("max-depth", 0, "deepest directory level to descend into (1 = only dir itself); 0 = unlimited")
+		hidden      = flag.Bool("hidden", false, "include dot files and dot directories")
+		symlinks    = flag.String("symlinks", string(skipSymlinks), "symlink policy: skip or follow (with loop detection)")
+		hashName    = flag.String("hash", "", "hash contents while reading: sha256, xxhash or blake2b")
+		dupes       = flag.Bool("dupes", false, "report duplicate files (implies -hash=xxhash unless set)")
+		strategy    = flag.String("strategy", "", "how sizes are measured: stat, read or verify (stat and read, compare); default stat, or read when hashing")
+		archives    = flag.Bool("archives", false, "look inside zip, tar and tar.gz files and report their unpacked size")
+		arDepth     = flag.Int("archive-depth", 2, "with -archives, how many levels of nested archives to open")
+		out         = flag.String("out", "text", "report format: text, json, csv or tree")
+		top         = flag.Int("top", 10, "number of largest files to list")
+		timeout     = flag.Duration("timeout", 0, "stop and report a partial total after this long; 0 = no limit")
+		watchDir    = flag.Bool("watch", false, "keep running and print added, removed, grown and shrunk files")
+		interval    = flag.Duration("interval", 2*time.Second, "with -watch, how often to poll")
+		debounce    = flag.Duration("debounce", 500*time.Millisecond, "with -watch, wait until a file's mtime is this old before re-reading it")
+		cachePath   = flag.String("cache", "", "JSON file to reuse results for files whose size, mtime and inode are unchanged")
+		verifyCache = flag.Bool("verify-cache", false, "with -cache, measure every file anyway and report cached entries that were wrong")
+		showProg    = flag.Bool("progress", true, "show files done, bytes, throughput and ETA on stderr while scanning, when it is a terminal")
+		debug       = flag.Bool("debug", false, "log every worker, job and lock step to stderr")
 	)
+	flag.Var(&include, "include", "glob of files to count, matched against the name or the path relative to -dir (repeatable, comma-separated)")
+	flag.Var(&exclude, "exclude", "glob of files or directories to leave out (repeatable, comma-separated)")
+	flag.Parse()
 
-	for _, f := range files {
-		id := atomic.AddInt64(&nextID, 1)
-		path := f
+	usage := func(format string, args ...any) {
+		fmt.Fprintf(os.Stderr, "error: "+format+"\n", args...)
+		os.Exit(exitUsage)
+	}
+	algo := hashAlgo(*hashName)
+	if !algo.valid() {
+		usage("unknown hash %q", *hashName)
+	}
+	if *dupes && algo == noHash {
+		algo = xxhashH
+	}
+	strat := sizeStrategy(*strategy)
+	switch {
+	case strat == "" && algo != noHash:
+		strat = readSize
+	case strat == "":
+		strat = statSize
+	case !strat.valid():
+		usage("unknown strategy %q", *strategy)
+	case !strat.reads() && algo != noHash:
+		usage("-hash and -dupes need -strategy=read or verify")
+	}
+	if *watchDir && *interval <= 0 {
+		usage("-interval must be positive")
+	}
+	switch *out {
+	case "text", "json", "csv", "tree":
+	default:
+		usage("unknown output format %q", *out)
+	}
 
-		log.Printf("[main] wg.Add(1) for job=%d file=%s", id, path)
-		wg.Add(1)
+	depth := 0
+	if *archives {
+		if *arDepth < 1 {
+			usage("-archive-depth must be at least 1")
+		}
+		depth = *arDepth
+	}
 
-		log.Printf("[main] launching goroutine for job=%d", id)
-		go func(jobID int64, p string) {
-			defer func() {
-				log.Printf("[job %d] DONE → calling wg.Done()", jobID)
-				wg.Done()
-			}()
+	log.SetFlags(log.Ltime | log.Lmicroseconds)
+	if !*debug {
+		log.SetOutput(io.Discard)
+	}
 
-			log.Printf("[job %d] START processing file=%s", jobID, p)
+	var cache *scanCache
+	if *cachePath != "" {
+		var err error
+		cache, err = loadCache(*cachePath, *verifyCache)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v; starting with an empty cache\n", err)
+		}
+	}
 
-			start := time.Now()
-			size, err := getFileSize(jobID, p)
-			if err != nil {
-				log.Printf("[job %d] error reading %s: %v", jobID, p, err)
-				return
-			}
-			elapsed := time.Since(start)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cfg := cliConfig{
+		dir: *dir,
+		walk: walkConfig{
+			include:  include,
+			exclude:  exclude,
+			maxDepth: *maxDepth,
+			hidden:   *hidden,
+			symlinks: symlinkPolicy(*symlinks),
+		},
+		scan:    scanConfig{workers: *workers, strategy: strat, hash: algo, archiveDepth: depth, cache: cache},
+		dupes:   *dupes,
+		out:     *out,
+		top:     *top,
+		timeout: *timeout,
+		// the debug log shares stderr and would tear the redrawn line
+		progress: *showProg && !*debug && isTerminal(os.Stderr),
+
+		watch:    *watchDir,
+		interval: *interval,
+		debounce: *debounce,
+	}
+	if cfg.watch {
+		os.Exit(watch(ctx, cfg, os.Stdout, os.Stderr))
+	}
+	os.Exit(run(ctx, cfg, os.Stdout, os.Stderr))
+}
 
-			log.Printf("[job %d] got size=%dB in %s → acquiring lock", jobID, size, elapsed)
+// run walks and scans cfg.dir, writes the report to stdout and errors to
+// stderr, and returns the process exit code.
+func run(ctx context.Context, cfg cliConfig, stdout, stderr io.Writer) int {
+	if cfg.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.timeout)
+		defer cancel()
+	}
+	log.Printf("[main] start. scanning dir=%q", cfg.dir)
 
-			mu.Lock()
-			log.Printf("[job %d] LOCKED. total(before)=%d", jobID, totalSize)
-			totalSize += size
-			log.Printf("[job %d] updated total(after)=%d → unlocking", jobID, totalSize)
-			mu.Unlock()
+	prog := newProgress(time.Now())
+	cfg.walk.progress, cfg.scan.progress = prog, prog
+	stop := func() {}
+	if cfg.progress {
+		stop = prog.show(stderr, progressEvery)
+	}
 
-			log.Printf("[job %d] UNLOCKED. file=%s, size=%dB", jobID, p, size)
-		}(id, path)
+	files, walkErrs, err := walkFiles(ctx, cfg.dir, cfg.walk)
+	if err != nil && ctx.Err() == nil {
+		stop()
+		fmt.Fprintln(stderr, "error:", err)
+		return exitUsage
 	}
+	prog.walkDone()
+	log.Printf("[main] found %d files", len(files))
 
-	log.Printf("[main] all goroutines launched → entering wg.Wait() (blocking)")
-	wg.Wait()
-	log.Printf("[main] wg.Wait() returned (all jobs done)")
+	res := scanFiles(ctx, files, cfg.scan)
+	stop()
+	end := time.Now()
+	res.Errors = append(walkErrs, res.Errors...)
+	res.Incomplete = res.Incomplete || err != nil
+
+	r := buildReport(cfg.dir, res, cfg.top)
+	r.Hash = cfg.scan.hash
+	r.Elapsed = end.Sub(prog.start)
+	r.Throughput = prog.rate(end)
+	if r.Incomplete {
+		r.StopReason = stopReason(ctx.Err())
+	}
+	if cfg.dupes {
+		r.Duplicates = findDuplicates(res.Entries)
+	}
+	if c := cfg.scan.cache; c != nil {
+		if !res.Incomplete {
+			c.prune(cfg.dir, res.Entries)
+		}
+		saveCache(c, stderr)
+	}
+	if err := writeReport(stdout, cfg.out, r); err != nil {
+		fmt.Fprintln(stderr, "error:", err)
+		return exitUsage
+	}
+	for _, e := range res.Errors {
+		fmt.Fprintln(stderr, "error:", e)
+	}
 
-	fmt.Printf("Total size: %d bytes\n", totalSize)
+	switch {
+	case res.Incomplete:
+		log.Printf("[main] exit: scan incomplete")
+		return exitIncomplete
+	case len(res.Errors) > 0:
+		log.Printf("[main] exit with %d errors", len(res.Errors))
+		return exitFileErrors
+	}
 	log.Printf("[main] exit")
+	return exitOK
 }
 
-func getFileSize(jobID int64, path string) (int64, error) {
-	log.Printf("[job %d] opening file=%s", jobID, path)
-	f, err := os.Open(path)
-	if err != nil {
-		return 0, err
+// saveCache writes c back and warns about problems on stderr; a cache that
+// cannot be saved only costs time on the next run.
+func saveCache(c *scanCache, stderr io.Writer) {
+	hits, misses, stale := c.Stats()
+	log.Printf("[cache] %d hits, %d misses, %d stale", hits, misses, stale)
+	if stale > 0 {
+		fmt.Fprintf(stderr, "warning: %d cached entries no longer matched their files\n", stale)
 	}
-	defer func() {
-		_ = f.Close()
-		log.Printf("[job %d] closed file=%s", jobID, path)
-	}()
-
-	// time.Sleep(50 * time.Millisecond)
+	if err := c.save(); err != nil {
+		fmt.Fprintln(stderr, "warning: saving cache:", err)
+	}
+}
 
-	n, err := io.Copy(io.Discard, f)
-	log.Printf("[job %d] io.Copy done bytes=%d err=%v", jobID, n, err)
-	return n, err
+func stopReason(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "timeout"
+	}
+	return "interrupted"
 }
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// progressEvery is how often the progress line is redrawn.
+const progressEvery = 500 * time.Millisecond
+
+// progress counts the work of one run. The walk and the workers update it
+// with sync/atomic, like nextID in scanFiles, so reporting never makes a
+// worker wait for a lock. A nil *progress ignores every update.
+type progress struct {
+	start      time.Time
+	discovered int64 // files found by the walk so far
+	done       int64 // files measured, failed or canceled
+	bytes      int64 // size of the files measured
+	walked     int32 // 1 once the walk is over and discovered is final
+}
+
+func newProgress(start time.Time) *progress { return &progress{start: start} }
+
+func (p *progress) found() {
+	if p != nil {
+		atomic.AddInt64(&p.discovered, 1)
+	}
+}
+
+func (p *progress) walkDone() {
+	if p != nil {
+		atomic.StoreInt32(&p.walked, 1)
+	}
+}
+
+func (p *progress) finished(size int64) {
+	if p != nil {
+		atomic.AddInt64(&p.done, 1)
+		atomic.AddInt64(&p.bytes, size)
+	}
+}
+
+// rate is the average number of bytes measured per second up to now.
+func (p *progress) rate(now time.Time) float64 {
+	elapsed := now.Sub(p.start).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(atomic.LoadInt64(&p.bytes)) / elapsed
+}
+
+// line renders the counters as of now, e.g.
+// "1200/5000 files  1.4 GiB  38.2 MiB/s  ETA 2m5s". While the walk is still
+// running the file total is marked with a "+" and there is no ETA yet; the
+// ETA assumes the remaining files take as long on average as the done ones.
+func (p *progress) line(now time.Time) string {
+	done := atomic.LoadInt64(&p.done)
+	discovered := atomic.LoadInt64(&p.discovered)
+	walked := atomic.LoadInt32(&p.walked) == 1
+
+	total := fmt.Sprint(discovered)
+	if !walked {
+		total += "+"
+	}
+	s := fmt.Sprintf("%d/%s files  %s  %s/s", done, total,
+		humanBytes(atomic.LoadInt64(&p.bytes)), humanBytes(int64(p.rate(now))))
+	if walked && done > 0 && done < discovered {
+		elapsed := now.Sub(p.start)
+		eta := time.Duration(float64(elapsed) * float64(discovered-done) / float64(done))
+		s += "  ETA " + eta.Round(time.Second).String()
+	}
+	return s
+}
+
+// show redraws the progress line on w, which should be a terminal, until
+// the returned stop is called. stop erases the line so whatever is printed
+// next starts on a clean one.
+func (p *progress) show(w io.Writer, every time.Duration) (stop func()) {
+	quit := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		t := time.NewTicker(every)
+		defer t.Stop()
+		for {
+			select {
+			case now := <-t.C:
+				fmt.Fprintf(w, "\r\033[K%s", p.line(now))
+			case <-quit:
+				fmt.Fprint(w, "\r\033[K")
+				return
+			}
+		}
+	}()
+	return func() {
+		close(quit)
+		wg.Wait()
+	}
+}
+
+// isTerminal reports whether f is a character device such as a terminal,
+// rather than a file or a pipe where a redrawn line would only be noise.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
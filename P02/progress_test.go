@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgressLine(t *testing.T) {
+	start := time.Unix(1000, 0)
+	p := newProgress(start)
+	for i := 0; i < 4; i++ {
+		p.found()
+	}
+	p.finished(3 << 20)
+	if got, want := p.line(start.Add(time.Second)), "1/4+ files  3.0 MiB  3.0 MiB/s"; got != want {
+		t.Fatalf("while walking: %q, want %q", got, want)
+	}
+
+	p.walkDone()
+	if got, want := p.line(start.Add(2*time.Second)), "1/4 files  3.0 MiB  1.5 MiB/s  ETA 6s"; got != want {
+		t.Fatalf("after walk: %q, want %q", got, want)
+	}
+	for i := 0; i < 3; i++ {
+		p.finished(0)
+	}
+	if got := p.line(start.Add(4 * time.Second)); strings.Contains(got, "ETA") {
+		t.Fatalf("finished scan still has an ETA: %q", got)
+	}
+
+	var nilProgress *progress
+	nilProgress.found()
+	nilProgress.finished(1)
+	nilProgress.walkDone()
+}
+
+func TestProgressCounts(t *testing.T) {
+	dir := t.TempDir()
+	files := writeFiles(t, dir, map[string]int{"a": 10, "b": 20, "sub/c": 30})
+	p := newProgress(time.Now())
+
+	found, _, err := walkFiles(context.Background(), dir, walkConfig{progress: p})
+	if err != nil || p.discovered != int64(len(files)) {
+		t.Fatalf("discovered=%d err=%v, want %d", p.discovered, err, len(files))
+	}
+	missing := filepath.Join(dir, "missing")
+	scanFiles(context.Background(), append(found, missing), scanConfig{workers: 3, strategy: statSize, progress: p})
+	if p.done != 4 || p.bytes != 60 {
+		t.Fatalf("done=%d bytes=%d, want 4 and 60", p.done, p.bytes)
+	}
+}
+
+func TestProgressShow(t *testing.T) {
+	p := newProgress(time.Now())
+	p.found()
+	var buf bytes.Buffer
+	stop := p.show(&buf, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	stop()
+
+	out := buf.String()
+	if !strings.Contains(out, "\r\033[K0/1+ files") || !strings.HasSuffix(out, "\r\033[K") {
+		t.Fatalf("unexpected progress output %q", out)
+	}
+}
+
+func TestRunReportsElapsed(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]int{"a": 10, "b": 20})
+	cfg := cliConfig{dir: dir, scan: scanConfig{workers: 2, strategy: readSize}, out: "json", top: 10}
+
+	var stdout, stderr bytes.Buffer
+	if code := run(context.Background(), cfg, &stdout, &stderr); code != exitOK {
+		t.Fatalf("exit %d: %s", code, stderr.String())
+	}
+	var r Report
+	if err := json.Unmarshal(stdout.Bytes(), &r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Elapsed <= 0 || r.Throughput <= 0 {
+		t.Fatalf("elapsed=%v throughput=%v, want both set", r.Elapsed, r.Throughput)
+	}
+	if stderr.Len() != 0 {
+		t.Fatalf("progress written without a terminal: %q", stderr.String())
+	}
+}
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DirTotal rolls up every file below a directory, at any depth.
+type DirTotal struct {
+	Path      string `json:"path"`
+	Size      int64  `json:"size"`
+	Allocated int64  `json:"allocated,omitempty"`
+	Files     int    `json:"files"`
+}
+
+// ExtTotal sums the files sharing an extension; files without one use "".
+type ExtTotal struct {
+	Ext   string `json:"ext"`
+	Size  int64  `json:"size"`
+	Files int    `json:"files"`
+}
+
+// Report is everything a scan found, ready to be rendered.
+type Report struct {
+	Root       string           `json:"root"`
+	TotalSize  int64            `json:"total_size"`
+	Allocated  int64            `json:"allocated,omitempty"`
+	Files      []FileEntry      `json:"files"`
+	Dirs       []DirTotal       `json:"dirs"`           // largest first
+	Extensions []ExtTotal       `json:"extensions"`     // largest first
+	Largest    []FileEntry      `json:"largest"`        // top N files, largest first
+	Hash       hashAlgo         `json:"hash,omitempty"` // algorithm behind FileEntry.Hash
+	Duplicates []DuplicateGroup `json:"duplicates,omitempty"`
+	Errors     []string         `json:"errors,omitempty"`
+
+	// Incomplete marks a scan cut short by StopReason ("interrupted" or
+	// "timeout"); Skipped files were found but not measured.
+	Incomplete bool   `json:"incomplete,omitempty"`
+	StopReason string `json:"stop_reason,omitempty"`
+	Skipped    int    `json:"skipped,omitempty"`
+
+	// Elapsed is the wall time of the walk and scan, and Throughput the
+	// bytes measured per second over it; both are zero when not timed.
+	Elapsed    time.Duration `json:"elapsed_ns,omitempty"`
+	Throughput float64       `json:"bytes_per_second,omitempty"`
+}
+
+// buildReport aggregates res by directory and extension. Directories are
+// rolled up to root, so the root's total equals TotalSize.
+func buildReport(root string, res scanResult, topN int) Report {
+	root = filepath.Clean(root)
+	r := Report{
+		Root:       root,
+		TotalSize:  res.TotalSize,
+		Allocated:  res.Allocated,
+		Files:      res.Entries,
+		Incomplete: res.Incomplete,
+		Skipped:    res.Skipped,
+	}
+	for _, e := range res.Errors {
+		r.Errors = append(r.Errors, e.Error())
+	}
+
+	dirs := map[string]*DirTotal{root: {Path: root}}
+	exts := map[string]*ExtTotal{}
+	for _, e := range res.Entries {
+		for d := filepath.Dir(e.Path); ; d = filepath.Dir(d) {
+			if !isAncestor(root, d) {
+				d = root // a file given as root, or a path outside it
+			}
+			t := dirs[d]
+			if t == nil {
+				t = &DirTotal{Path: d}
+				dirs[d] = t
+			}
+			t.Size += e.Size
+			t.Allocated += e.Allocated
+			t.Files++
+			if d == root {
+				break
+			}
+		}
+
+		ext := strings.ToLower(filepath.Ext(e.Path))
+		t := exts[ext]
+		if t == nil {
+			t = &ExtTotal{Ext: ext}
+			exts[ext] = t
+		}
+		t.Size += e.Size
+		t.Files++
+	}
+
+	for _, t := range dirs {
+		r.Dirs = append(r.Dirs, *t)
+	}
+	sort.Slice(r.Dirs, func(i, j int) bool {
+		if r.Dirs[i].Size != r.Dirs[j].Size {
+			return r.Dirs[i].Size > r.Dirs[j].Size
+		}
+		return r.Dirs[i].Path < r.Dirs[j].Path
+	})
+	for _, t := range exts {
+		r.Extensions = append(r.Extensions, *t)
+	}
+	sort.Slice(r.Extensions, func(i, j int) bool {
+		if r.Extensions[i].Size != r.Extensions[j].Size {
+			return r.Extensions[i].Size > r.Extensions[j].Size
+		}
+		return r.Extensions[i].Ext < r.Extensions[j].Ext
+	})
+
+	r.Largest = append([]FileEntry(nil), res.Entries...)
+	sort.SliceStable(r.Largest, func(i, j int) bool { return r.Largest[i].Size > r.Largest[j].Size })
+	if topN >= 0 && len(r.Largest) > topN {
+		r.Largest = r.Largest[:topN]
+	}
+	return r
+}
+
+// writeReport renders r as "text", "json", "csv" or "tree".
+func writeReport(w io.Writer, format string, r Report) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case "csv":
+		return writeCSV(w, r)
+	case "tree":
+		return writeTree(w, r)
+	case "text", "":
+		return writeText(w, r)
+	}
+	return fmt.Errorf("unknown output format %q", format)
+}
+
+func writeText(w io.Writer, r Report) error {
+	fmt.Fprintf(w, "Total size: %d bytes (%s, %d files)\n", r.TotalSize, humanBytes(r.TotalSize), len(r.Files))
+	writeIncomplete(w, r)
+	if r.Allocated > 0 {
+		fmt.Fprintf(w, "On disk: %d bytes (%s)\n", r.Allocated, humanBytes(r.Allocated))
+	}
+	writeElapsed(w, r)
+	if len(r.Largest) > 0 {
+		fmt.Fprintf(w, "\nLargest files:\n")
+		for _, e := range r.Largest {
+			fmt.Fprintf(w, "  %9s  %s\n", humanBytes(e.Size), e.Path)
+		}
+	}
+	if len(r.Extensions) > 0 {
+		fmt.Fprintf(w, "\nBy extension:\n")
+		for _, t := range r.Extensions {
+			ext := t.Ext
+			if ext == "" {
+				ext = "(none)"
+			}
+			fmt.Fprintf(w, "  %9s  %6d  %s\n", humanBytes(t.Size), t.Files, ext)
+		}
+	}
+	var archives []FileEntry
+	for _, e := range r.Files {
+		if e.Archive != nil {
+			archives = append(archives, e)
+		}
+	}
+	if len(archives) > 0 {
+		fmt.Fprintf(w, "\nArchives:\n")
+		for _, e := range archives {
+			fmt.Fprintf(w, "  %9s  %s  %s\n", humanBytes(e.Size), archiveSummary(e.Archive), e.Path)
+			writeNested(w, e.Archive.Nested, "    ")
+		}
+	}
+	if len(r.Duplicates) > 0 {
+		fmt.Fprintln(w)
+		writeDuplicates(w, r.Duplicates, r.Hash)
+	}
+	return nil
+}
+
+// writeIncomplete marks a scan cut short; it prints nothing otherwise.
+func writeIncomplete(w io.Writer, r Report) {
+	if r.Incomplete {
+		fmt.Fprintf(w, "INCOMPLETE (%s): %d files found but not measured\n", r.StopReason, r.Skipped)
+	}
+}
+
+// writeElapsed prints the scan time and throughput when the scan was timed.
+func writeElapsed(w io.Writer, r Report) {
+	if r.Elapsed > 0 {
+		prec := time.Millisecond
+		if r.Elapsed < time.Second {
+			prec = time.Microsecond
+		}
+		fmt.Fprintf(w, "Elapsed: %s (%s/s)\n", r.Elapsed.Round(prec), humanBytes(int64(r.Throughput)))
+	}
+}
+
+func writeNested(w io.Writer, nested []ArchiveInfo, indent string) {
+	for _, a := range nested {
+		fmt.Fprintf(w, "%s%s  %s\n", indent, a.Name, archiveSummary(&a))
+		writeNested(w, a.Nested, indent+"  ")
+	}
+}
+
+// archiveSummary reads e.g. "tar.gz: 12 entries, 3.4 MiB unpacked (3.40x)".
+func archiveSummary(a *ArchiveInfo) string {
+	return fmt.Sprintf("%s: %d entries, %s unpacked (%.2fx)", a.Format, a.Entries, humanBytes(a.Uncompressed), a.Ratio)
+}
+
+// writeCSV emits one row per file, directory, extension, archive and
+// duplicate copy, told apart by the first column. Archive rows carry the
+// uncompressed size and the entry count. Trailing "scan" rows hold one
+// key/value pair each, key in the path column and value in the size column:
+// incomplete always, stop_reason and skipped for a scan cut short, and
+// elapsed_ns and bytes_per_second when the scan was timed.
+func writeCSV(w io.Writer, r Report) error {
+	cw := csv.NewWriter(w)
+	itoa := func(n int64) string { return strconv.FormatInt(n, 10) }
+	_ = cw.Write([]string{"type", "path", "size", "allocated", "files", "hash"})
+	for _, e := range r.Files {
+		_ = cw.Write([]string{"file", e.Path, itoa(e.Size), itoa(e.Allocated), "1", e.Hash})
+	}
+	for _, d := range r.Dirs {
+		_ = cw.Write([]string{"dir", d.Path, itoa(d.Size), itoa(d.Allocated), strconv.Itoa(d.Files), ""})
+	}
+	for _, t := range r.Extensions {
+		_ = cw.Write([]string{"ext", t.Ext, itoa(t.Size), "", strconv.Itoa(t.Files), ""})
+	}
+	for _, e := range r.Files {
+		if e.Archive != nil {
+			_ = cw.Write([]string{"archive", e.Path, itoa(e.Archive.Uncompressed), "", strconv.Itoa(e.Archive.Entries), ""})
+		}
+	}
+	for _, g := range r.Duplicates {
+		for _, p := range g.Paths {
+			_ = cw.Write([]string{"dup", p, itoa(g.Size), "", strconv.Itoa(len(g.Paths)), g.Hash})
+		}
+	}
+	scan := func(key, value string) { _ = cw.Write([]string{"scan", key, value, "", "", ""}) }
+	scan("incomplete", strconv.FormatBool(r.Incomplete))
+	if r.Incomplete {
+		scan("stop_reason", r.StopReason)
+		scan("skipped", strconv.Itoa(r.Skipped))
+	}
+	if r.Elapsed > 0 {
+		scan("elapsed_ns", itoa(int64(r.Elapsed)))
+		scan("bytes_per_second", strconv.FormatFloat(r.Throughput, 'f', 0, 64))
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeTree prints a du -a style tree: every directory and file with its
+// size, children indented under their parent and largest first. A footer
+// after the tree marks an incomplete scan and gives the elapsed time.
+func writeTree(w io.Writer, r Report) error {
+	type node struct {
+		name     string
+		size     int64
+		dir      bool
+		archive  *ArchiveInfo
+		children []*node
+	}
+	nodes := map[string]*node{}
+	get := func(p string, dir bool) *node {
+		n := nodes[p]
+		if n == nil {
+			n = &node{name: filepath.Base(p), dir: dir}
+			nodes[p] = n
+		}
+		return n
+	}
+	rootNode := get(r.Root, true)
+	rootNode.name = r.Root
+	for _, d := range r.Dirs {
+		n := get(d.Path, true)
+		n.size = d.Size
+		if d.Path != r.Root {
+			parent := get(filepath.Dir(d.Path), true)
+			parent.children = append(parent.children, n)
+		}
+	}
+	for _, e := range r.Files {
+		if e.Path == r.Root {
+			continue // root is a single file
+		}
+		n := get(e.Path, false)
+		n.size = e.Size
+		n.archive = e.Archive
+		parent := get(filepath.Dir(e.Path), true)
+		parent.children = append(parent.children, n)
+	}
+
+	var print func(n *node, indent string) error
+	print = func(n *node, indent string) error {
+		name := n.name
+		if n.dir && n != rootNode {
+			name += string(filepath.Separator)
+		}
+		if n.archive != nil {
+			name += "  [" + archiveSummary(n.archive) + "]"
+		}
+		if _, err := fmt.Fprintf(w, "%9s  %s%s\n", humanBytes(n.size), indent, name); err != nil {
+			return err
+		}
+		sort.Slice(n.children, func(i, j int) bool {
+			if n.children[i].size != n.children[j].size {
+				return n.children[i].size > n.children[j].size
+			}
+			return n.children[i].name < n.children[j].name
+		})
+		for _, c := range n.children {
+			if err := print(c, indent+"  "); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := print(rootNode, ""); err != nil {
+		return err
+	}
+	writeIncomplete(w, r)
+	writeElapsed(w, r)
+	return nil
+}
+
+// humanBytes formats n with binary units, e.g. 1536 → "1.5 KiB".
+func humanBytes(n int64) string {
+	const unit = 1024
+	if n < unit && n > -unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	v := float64(n)
+	i := 0
+	for ; (v >= unit || v <= -unit) && i < 6; i++ {
+		v /= unit
+	}
+	return fmt.Sprintf("%.1f %ciB", v, "KMGTPE"[i-1])
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func reportFixture() scanResult {
+	p := filepath.FromSlash
+	return scanResult{
+		TotalSize: 3100,
+		Files:     4,
+		Entries: []FileEntry{
+			{Path: p("r/a.txt"), Size: 100},
+			{Path: p("r/sub/b.bin"), Size: 2000},
+			{Path: p("r/sub/deep/c.BIN"), Size: 500},
+			{Path: p("r/Makefile"), Size: 500},
+		},
+	}
+}
+
+func TestBuildReport(t *testing.T) {
+	p := filepath.FromSlash
+	r := buildReport("r/", reportFixture(), 2)
+
+	wantDirs := []DirTotal{
+		{Path: "r", Size: 3100, Files: 4},
+		{Path: p("r/sub"), Size: 2500, Files: 2},
+		{Path: p("r/sub/deep"), Size: 500, Files: 1},
+	}
+	if !reflect.DeepEqual(r.Dirs, wantDirs) {
+		t.Fatalf("dirs got=%+v, want=%+v", r.Dirs, wantDirs)
+	}
+	wantExts := []ExtTotal{{".bin", 2500, 2}, {"", 500, 1}, {".txt", 100, 1}}
+	if !reflect.DeepEqual(r.Extensions, wantExts) {
+		t.Fatalf("extensions got=%+v, want=%+v", r.Extensions, wantExts)
+	}
+	if len(r.Largest) != 2 || r.Largest[0].Size != 2000 || r.Largest[1].Path != p("r/sub/deep/c.BIN") {
+		t.Fatalf("largest got=%+v", r.Largest)
+	}
+	if r.Root != "r" || r.TotalSize != 3100 || len(r.Files) != 4 {
+		t.Fatalf("report got=%+v", r)
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	r := buildReport("r", reportFixture(), 10)
+
+	var buf bytes.Buffer
+	if err := writeReport(&buf, "json", r); err != nil {
+		t.Fatal(err)
+	}
+	var back Report
+	if err := json.Unmarshal(buf.Bytes(), &back); err != nil {
+		t.Fatalf("json: %v\n%s", err, buf.String())
+	}
+	if !reflect.DeepEqual(back, r) {
+		t.Fatalf("json round trip got=%+v, want=%+v", back, r)
+	}
+
+	buf.Reset()
+	if err := writeReport(&buf, "csv", r); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1+4+3+3+1 || rows[0][0] != "type" || rows[5][0] != "dir" || rows[5][2] != "3100" ||
+		!reflect.DeepEqual(rows[11][:3], []string{"scan", "incomplete", "false"}) {
+		t.Fatalf("csv rows=%v", rows)
+	}
+
+	buf.Reset()
+	if err := writeReport(&buf, "tree", r); err != nil {
+		t.Fatal(err)
+	}
+	sep := string(filepath.Separator)
+	want := strings.Join([]string{
+		"  3.0 KiB  r",
+		"  2.4 KiB    sub" + sep,
+		"  2.0 KiB      b.bin",
+		"    500 B      deep" + sep,
+		"    500 B        c.BIN",
+		"    500 B    Makefile",
+		"    100 B    a.txt",
+		"",
+	}, "\n")
+	if buf.String() != want {
+		t.Fatalf("tree got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	if err := writeReport(&buf, "xml", r); err == nil {
+		t.Fatal("unknown format: want error")
+	}
+}
+
+func TestHumanBytes(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{5 << 20, "5.0 MiB"},
+		{3 << 40, "3.0 TiB"},
+		{1 << 62, "4.0 EiB"},
+	}
+	for _, tt := range tests {
+		if got := humanBytes(tt.n); got != tt.want {
+			t.Errorf("humanBytes(%d)=%q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestWriteReportIncomplete(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]int{"a.txt": 10, "b/c.bin": 20})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var stdout, stderr bytes.Buffer
+	cfg := cliConfig{dir: dir, scan: scanConfig{workers: 2, strategy: statSize}, out: "csv", top: 5}
+	if code := run(ctx, cfg, &stdout, &stderr); code != exitIncomplete {
+		t.Fatalf("csv: code=%d stderr=%s", code, stderr.String())
+	}
+	rows, err := csv.NewReader(&stdout).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	scan := map[string]string{}
+	for _, row := range rows {
+		if row[0] == "scan" {
+			scan[row[1]] = row[2]
+		}
+	}
+	if scan["incomplete"] != "true" || scan["stop_reason"] != "interrupted" || scan["skipped"] == "" || scan["elapsed_ns"] == "" || scan["bytes_per_second"] == "" {
+		t.Fatalf("csv scan rows=%v", scan)
+	}
+
+	stdout.Reset()
+	cfg.out = "tree"
+	if code := run(ctx, cfg, &stdout, &stderr); code != exitIncomplete {
+		t.Fatalf("tree: code=%d stderr=%s", code, stderr.String())
+	}
+	lines := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+	if n := len(lines); n < 2 || !strings.HasPrefix(lines[n-2], "INCOMPLETE (interrupted): ") || !strings.HasPrefix(lines[n-1], "Elapsed: ") {
+		t.Fatalf("tree footer missing:\n%s", stdout.String())
+	}
+}
+
+func TestRunExitCodes(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]int{"a.txt": 10, "b/c.bin": 20})
+
+	var stdout, stderr bytes.Buffer
+	cfg := cliConfig{dir: dir, scan: scanConfig{workers: 2, strategy: statSize}, out: "text", top: 5}
+	if code := run(context.Background(), cfg, &stdout, &stderr); code != exitOK {
+		t.Fatalf("code=%d stderr=%s", code, stderr.String())
+	}
+	if !strings.HasPrefix(stdout.String(), "Total size: 30 bytes (30 B, 2 files)\n") {
+		t.Fatalf("stdout=%s", stdout.String())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	stdout.Reset()
+	if code := run(ctx, cfg, &stdout, &stderr); code != exitIncomplete || !strings.Contains(stdout.String(), "INCOMPLETE (interrupted)") {
+		t.Fatalf("canceled: code=%d stdout=%s", code, stdout.String())
+	}
+
+	cfg.dir = filepath.Join(dir, "missing")
+	if code := run(context.Background(), cfg, &stdout, &stderr); code != exitUsage {
+		t.Fatalf("missing dir: code=%d", code)
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// readChunk is how much getFileSize reads between cancellation checks.
+const readChunk = 256 << 10
+
+// FileError is a file that could not be measured.
+type FileError struct {
+	Path string
+	Err  error
+}
+
+func (e FileError) Error() string { return fmt.Sprintf("%s: %v", e.Path, e.Err) }
+
+func (e FileError) Unwrap() error { return e.Err }
+
+// FileEntry is one measured file.
+type FileEntry struct {
+	Path      string `json:"path"`
+	Size      int64  `json:"size"`                // apparent size in bytes
+	Allocated int64  `json:"allocated,omitempty"` // bytes of disk in use; 0 when unknown
+	Hash      string `json:"hash,omitempty"`      // hex digest; empty unless hashing was enabled
+
+	Archive *ArchiveInfo `json:"archive,omitempty"` // contents, when archives are inspected
+}
+
+type scanConfig struct {
+	workers  int          // max files processed at once; values < 1 mean 1
+	strategy sizeStrategy // how sizes are measured; the zero value reads
+	hash     hashAlgo     // content hash computed while reading; noHash skips it
+
+	archiveDepth int        // archive nesting levels to look into; 0 treats archives as plain files
+	cache        *scanCache // earlier results to reuse; nil measures everything
+	progress     *progress  // counts the files done; may be nil
+}
+
+type scanResult struct {
+	TotalSize int64
+	Allocated int64       // disk usage; 0 when the strategy or platform can't tell
+	Files     int         // files measured successfully
+	Entries   []FileEntry // one per measured file, sorted by path
+	Errors    []FileError
+
+	// Incomplete is set when ctx ended the scan early; TotalSize then only
+	// covers the Files that were measured in full and Skipped were not.
+	Incomplete bool
+	Skipped    int
+}
+
+// scanFiles measures files with a fixed pool of workers, so at most
+// cfg.workers files are open at any time no matter how many are queued.
+// Canceling ctx stops queueing files and aborts reads in progress.
+func scanFiles(ctx context.Context, files []string, cfg scanConfig) scanResult {
+	if len(files) == 0 {
+		return scanResult{}
+	}
+	workers := max(1, min(cfg.workers, len(files)))
+
+	var (
+		res    scanResult
+		mu     sync.Mutex // guards res
+		wg     sync.WaitGroup
+		nextID int64
+	)
+	jobs := make(chan string)
+
+	log.Printf("[main] starting %d workers for %d files", workers, len(files))
+	for w := 1; w <= workers; w++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer func() {
+				log.Printf("[worker %d] jobs drained → calling wg.Done()", worker)
+				wg.Done()
+			}()
+			for p := range jobs {
+				jobID := atomic.AddInt64(&nextID, 1)
+				log.Printf("[job %d] START processing file=%s on worker=%d", jobID, p, worker)
+
+				start := time.Now()
+				entry, err := measureFile(ctx, jobID, p, cfg)
+				if err != nil && !errors.Is(err, errBadArchive) {
+					mu.Lock()
+					if ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+						log.Printf("[job %d] canceled while reading %s", jobID, p)
+						res.Skipped++
+					} else {
+						log.Printf("[job %d] error reading %s: %v", jobID, p, err)
+						res.Errors = append(res.Errors, FileError{Path: p, Err: err})
+					}
+					mu.Unlock()
+					cfg.progress.finished(0)
+					continue
+				}
+				elapsed := time.Since(start)
+				size := entry.Size
+
+				log.Printf("[job %d] got size=%dB in %s → acquiring lock", jobID, size, elapsed)
+
+				mu.Lock()
+				log.Printf("[job %d] LOCKED. total(before)=%d", jobID, res.TotalSize)
+				res.TotalSize += size
+				res.Allocated += entry.Allocated
+				res.Files++
+				res.Entries = append(res.Entries, entry)
+				if err != nil { // counted, but its contents could not be listed
+					res.Errors = append(res.Errors, FileError{Path: p, Err: err})
+				}
+				log.Printf("[job %d] updated total(after)=%d → unlocking", jobID, res.TotalSize)
+				mu.Unlock()
+
+				log.Printf("[job %d] UNLOCKED. file=%s, size=%dB", jobID, p, size)
+				cfg.progress.finished(size)
+			}
+		}(w)
+	}
+
+	queued := 0
+feed:
+	for _, f := range files {
+		// select picks at random among ready cases, so check ctx first: a
+		// canceled scan must not keep handing files to idle workers
+		if ctx.Err() != nil {
+			log.Printf("[main] stopping: %v", ctx.Err())
+			break
+		}
+		select {
+		case jobs <- f:
+			queued++
+		case <-ctx.Done():
+			log.Printf("[main] stopping: %v", ctx.Err())
+			break feed
+		}
+	}
+	close(jobs)
+
+	log.Printf("[main] %d files queued → entering wg.Wait() (blocking)", queued)
+	wg.Wait()
+	log.Printf("[main] wg.Wait() returned (all jobs done)")
+
+	res.Skipped += len(files) - queued
+	res.Incomplete = res.Skipped > 0
+	sort.Slice(res.Entries, func(i, j int) bool { return res.Entries[i].Path < res.Entries[j].Path })
+	sort.Slice(res.Errors, func(i, j int) bool { return res.Errors[i].Path < res.Errors[j].Path })
+	return res
+}
+
+// getFileSize counts the bytes of path by reading it, checking ctx between
+// chunks, and feeds them to h unless h is nil. On cancellation it returns
+// the bytes read so far and ctx.Err().
+func getFileSize(ctx context.Context, jobID int64, path string, h hash.Hash) (int64, error) {
+	log.Printf("[job %d] opening file=%s", jobID, path)
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = f.Close()
+		log.Printf("[job %d] closed file=%s", jobID, path)
+	}()
+
+	// time.Sleep(50 * time.Millisecond)
+
+	var n int64
+	buf := make([]byte, readChunk)
+	for {
+		if err := ctx.Err(); err != nil {
+			return n, err
+		}
+		m, err := f.Read(buf)
+		n += int64(m)
+		if h != nil {
+			h.Write(buf[:m]) // never returns an error
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return n, err
+		}
+	}
+	log.Printf("[job %d] read done bytes=%d", jobID, n)
+	return n, nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFiles creates name → size files under dir and returns their paths.
+func writeFiles(t testing.TB, dir string, sizes map[string]int) []string {
+	t.Helper()
+	var paths []string
+	for name, n := range sizes {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, make([]byte, n), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, p)
+	}
+	return paths
+}
+
+func TestScanFiles(t *testing.T) {
+	dir := t.TempDir()
+	sizes := map[string]int{}
+	want := int64(0)
+	for i := 0; i < 50; i++ {
+		n := i * 37
+		sizes[fmt.Sprintf("f/%02d.bin", i)] = n
+		want += int64(n)
+	}
+	files := writeFiles(t, dir, sizes)
+
+	for _, workers := range []int{0, 1, 4, 100} {
+		res := scanFiles(context.Background(), files, scanConfig{workers: workers})
+		if res.TotalSize != want || res.Files != len(files) || len(res.Errors) != 0 {
+			t.Fatalf("workers=%d: got total=%d files=%d errors=%v, want total=%d files=%d",
+				workers, res.TotalSize, res.Files, res.Errors, want, len(files))
+		}
+	}
+}
+
+func TestScanFilesCollectsErrors(t *testing.T) {
+	dir := t.TempDir()
+	files := writeFiles(t, dir, map[string]int{"a": 10, "b": 20})
+	missing := []string{filepath.Join(dir, "zz-missing"), filepath.Join(dir, "m-missing")}
+	files = append(files, missing...)
+
+	res := scanFiles(context.Background(), files, scanConfig{workers: 2})
+	if res.TotalSize != 30 || res.Files != 2 {
+		t.Fatalf("got total=%d files=%d, want 30 and 2", res.TotalSize, res.Files)
+	}
+	if len(res.Errors) != 2 {
+		t.Fatalf("errors=%v, want 2", res.Errors)
+	}
+	if res.Errors[0].Path != missing[1] || res.Errors[1].Path != missing[0] {
+		t.Fatalf("errors not sorted by path: %v", res.Errors)
+	}
+	if !errors.Is(res.Errors[0], fs.ErrNotExist) {
+		t.Fatalf("err=%v, want fs.ErrNotExist", res.Errors[0])
+	}
+}
+
+func TestScanFilesCanceled(t *testing.T) {
+	dir := t.TempDir()
+	files := writeFiles(t, dir, map[string]int{"a": 10, "b": 3 * readChunk, "c": 5})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// repeated because a worker that is already idle must never win the race
+	// against the canceled ctx, whatever the strategy
+	for i := 0; i < 20; i++ {
+		for _, strategy := range []sizeStrategy{readSize, statSize, verifySize} {
+			res := scanFiles(ctx, files, scanConfig{workers: 2, strategy: strategy})
+			if !res.Incomplete || res.Skipped != len(files) || res.Files != 0 || len(res.Errors) != 0 {
+				t.Fatalf("strategy %v: got %+v, want every file skipped and no errors", strategy, res)
+			}
+		}
+	}
+	if _, err := measureSize(ctx, 1, files[0], scanConfig{strategy: statSize}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("measureSize: err=%v, want context.Canceled", err)
+	}
+
+	n, err := getFileSize(ctx, 1, files[0], nil)
+	if n != 0 || !errors.Is(err, context.Canceled) {
+		t.Fatalf("getFileSize: n=%d err=%v, want 0 and context.Canceled", n, err)
+	}
+
+	full := scanFiles(context.Background(), files, scanConfig{workers: 2})
+	if full.Incomplete || full.Skipped != 0 || full.TotalSize != 15+3*readChunk {
+		t.Fatalf("uncanceled scan got %+v", full)
+	}
+}
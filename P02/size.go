@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+)
+
+// sizeStrategy is how a file's size is learned.
+type sizeStrategy string
+
+const (
+	statSize   sizeStrategy = "stat"   // metadata only: apparent size and allocated blocks
+	readSize   sizeStrategy = "read"   // count every byte; needed for hashing
+	verifySize sizeStrategy = "verify" // stat, then read and fail if the two disagree
+)
+
+func (s sizeStrategy) valid() bool {
+	switch s {
+	case statSize, readSize, verifySize:
+		return true
+	}
+	return false
+}
+
+// reads reports whether the strategy opens files and reads their contents.
+func (s sizeStrategy) reads() bool { return s != statSize }
+
+// stats reports whether the strategy stats files, which is what sets Allocated.
+func (s sizeStrategy) stats() bool { return s == statSize || s == verifySize }
+
+// errSizeMismatch means the bytes read differ from the size reported by stat,
+// usually because the file changed during the scan.
+var errSizeMismatch = errors.New("size changed between stat and read")
+
+// measureFile returns the cached entry for path when cfg.cache has an
+// up-to-date one, and measures the file otherwise.
+func measureFile(ctx context.Context, jobID int64, path string, cfg scanConfig) (FileEntry, error) {
+	c := cfg.cache
+	if c == nil {
+		return measureEntry(ctx, jobID, path, cfg)
+	}
+	cached, fi, hit := c.lookup(path, cfg)
+	if hit && !c.verify {
+		log.Printf("[job %d] cache hit for %s", jobID, path)
+		c.count(true, false)
+		return cached, nil
+	}
+	e, err := measureEntry(ctx, jobID, path, cfg)
+	if err != nil || fi == nil {
+		return e, err
+	}
+	stale := hit && (e.Size != cached.Size || e.Hash != cached.Hash)
+	if stale {
+		log.Printf("[job %d] cache entry for %s was stale", jobID, path)
+	}
+	c.count(hit, stale)
+	c.store(e, fi, cfg)
+	return e, nil
+}
+
+// measureEntry fills in an entry for path and, when cfg.archiveDepth allows,
+// lists the archive it holds. An archive that cannot be listed comes back
+// fully measured together with an error wrapping errBadArchive.
+func measureEntry(ctx context.Context, jobID int64, path string, cfg scanConfig) (FileEntry, error) {
+	e, err := measureSize(ctx, jobID, path, cfg)
+	if err != nil || cfg.archiveDepth < 1 || archiveFormat(path) == "" {
+		return e, err
+	}
+	log.Printf("[job %d] listing archive %s", jobID, path)
+	e.Archive, err = inspectArchive(ctx, path, e.Size, cfg.archiveDepth)
+	return e, err
+}
+
+// measureSize measures path according to cfg.strategy. With statSize and
+// verifySize, Allocated is set where the platform reports it, so sparse
+// files show Allocated < Size. A read cut short by ctx returns ctx.Err(), as
+// does a call made after ctx has ended.
+func measureSize(ctx context.Context, jobID int64, path string, cfg scanConfig) (FileEntry, error) {
+	e := FileEntry{Path: path}
+	if err := ctx.Err(); err != nil {
+		return e, err
+	}
+	if cfg.strategy.stats() {
+		fi, err := os.Lstat(path)
+		if err == nil && fi.Mode()&os.ModeSymlink != 0 {
+			fi, err = os.Stat(path) // the walk only hands out links it decided to follow
+		}
+		if err != nil {
+			return e, err
+		}
+		e.Size = fi.Size()
+		e.Allocated, _ = allocatedSize(fi)
+		if !cfg.strategy.reads() {
+			return e, nil
+		}
+	}
+
+	h := cfg.hash.newHash()
+	n, err := getFileSize(ctx, jobID, path, h)
+	if err != nil {
+		return e, err
+	}
+	if cfg.strategy == verifySize && n != e.Size {
+		return e, fmt.Errorf("%w: stat=%d read=%d", errSizeMismatch, e.Size, n)
+	}
+	e.Size = n
+	if h != nil {
+		e.Hash = hexSum(h)
+	}
+	return e, nil
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package main
+
+import "io/fs"
+
+// allocatedSize is not available on this platform.
+func allocatedSize(fs.FileInfo) (int64, bool) { return 0, false }
+
+// inode is not available on this platform; size and mtime have to do.
+func inode(fs.FileInfo) uint64 { return 0 }
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMeasureFileStrategies(t *testing.T) {
+	dir := t.TempDir()
+	files := writeFiles(t, dir, map[string]int{"a": 0, "b": 1000, "c": readChunk + 7})
+
+	for _, strategy := range []sizeStrategy{statSize, readSize, verifySize} {
+		res := scanFiles(context.Background(), files, scanConfig{workers: 2, strategy: strategy})
+		if res.TotalSize != 1007+readChunk || res.Files != 3 || len(res.Errors) != 0 {
+			t.Fatalf("%s: got %+v", strategy, res)
+		}
+	}
+
+	e, err := measureFile(context.Background(), 1, filepath.Join(dir, "missing"), scanConfig{strategy: statSize})
+	if !os.IsNotExist(err) {
+		t.Fatalf("missing file: entry=%+v err=%v", e, err)
+	}
+	if _, err := measureFile(context.Background(), 1, files[0], scanConfig{strategy: statSize, hash: sha256H}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMeasureFileSparse(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "sparse")
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const size = 64 << 20
+	if err := f.Truncate(size); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	e, err := measureFile(context.Background(), 1, p, scanConfig{strategy: statSize})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Size != size {
+		t.Fatalf("apparent size got=%d, want=%d", e.Size, size)
+	}
+	if fi, _ := os.Stat(p); e.Allocated == 0 {
+		if _, ok := allocatedSize(fi); !ok {
+			t.Skip("allocated size not reported on this platform")
+		}
+	}
+	if e.Allocated >= size {
+		t.Skipf("filesystem does not support sparse files (allocated=%d)", e.Allocated)
+	}
+
+	e, err = measureFile(context.Background(), 1, p, scanConfig{strategy: verifySize})
+	if err != nil || e.Size != size {
+		t.Fatalf("verify: entry=%+v err=%v", e, err)
+	}
+}
+
+func TestMeasureFileFollowsLinks(t *testing.T) {
+	dir := t.TempDir()
+	files := writeFiles(t, dir, map[string]int{"target": 123})
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(files[0], link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	for _, strategy := range []sizeStrategy{statSize, readSize} {
+		e, err := measureFile(context.Background(), 1, link, scanConfig{strategy: strategy})
+		if err != nil || e.Size != 123 {
+			t.Fatalf("%s: entry=%+v err=%v", strategy, e, err)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+//go:build unix
+
+package main
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// allocatedSize is the disk space held by the file: st_blocks is always in
+// 512-byte units regardless of the filesystem block size.
+func allocatedSize(fi fs.FileInfo) (int64, bool) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return int64(st.Blocks) * 512, true
+}
+
+// inode identifies the file on its device, so a path that now points at a
+// different file (e.g. after a rename over it) is not mistaken for the old one.
+func inode(fi fs.FileInfo) uint64 {
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Ino)
+	}
+	return 0
+}
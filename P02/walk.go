@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// symlinkPolicy decides what the walk does with symbolic links.
+type symlinkPolicy string
+
+const (
+	skipSymlinks   symlinkPolicy = "skip"   // ignore links entirely
+	followSymlinks symlinkPolicy = "follow" // walk link targets, each directory at most once
+)
+
+type walkConfig struct {
+	include  []string // glob patterns a file must match; empty means all
+	exclude  []string // glob patterns for files and directories to leave out
+	maxDepth int      // deepest level walked, 1 = entries directly under root; 0 = unlimited
+	hidden   bool     // include dot files and dot directories
+	symlinks symlinkPolicy
+
+	progress *progress // counts the files found; may be nil
+}
+
+// walker collects the regular files under a root. Patterns match either the
+// base name or the slash-separated path relative to the root, so "*.o" and
+// "build/*.o" both work. Errors on individual entries are collected and the
+// walk goes on.
+type walker struct {
+	ctx     context.Context
+	cfg     walkConfig
+	root    string
+	visited map[string]bool // resolved directories already walked
+	files   []string
+	errs    []FileError
+}
+
+// walkFiles returns the files under root selected by cfg, plus the entries
+// that could not be read. err is set for an invalid config or an unreadable
+// root, and to ctx.Err() when ctx ends the walk early; the files found up to
+// that point are still returned.
+func walkFiles(ctx context.Context, root string, cfg walkConfig) ([]string, []FileError, error) {
+	for _, p := range append(append([]string(nil), cfg.include...), cfg.exclude...) {
+		if _, err := filepath.Match(p, ""); err != nil {
+			return nil, nil, fmt.Errorf("pattern %q: %w", p, err)
+		}
+	}
+	switch cfg.symlinks {
+	case "":
+		cfg.symlinks = skipSymlinks
+	case skipSymlinks, followSymlinks:
+	default:
+		return nil, nil, fmt.Errorf("unknown symlink policy %q", cfg.symlinks)
+	}
+
+	real, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, nil, err
+	}
+	w := &walker{ctx: ctx, cfg: cfg, root: filepath.Clean(root), visited: map[string]bool{}}
+	if err := w.walk(real, w.root, 0); err != nil {
+		if ctx.Err() != nil {
+			return w.files, w.errs, err
+		}
+		return nil, nil, err
+	}
+	return w.files, w.errs, nil
+}
+
+// walk visits dir, which must be a resolved path, reporting entries under
+// logical, the path the user sees, at depth levels below the root.
+func (w *walker) walk(dir, logical string, depth int) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err := w.ctx.Err(); err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, p)
+		lp := filepath.Join(logical, rel)
+		if err != nil {
+			if p == dir && d == nil {
+				return err // the start of the walk itself is unreadable
+			}
+			w.errs = append(w.errs, FileError{Path: lp, Err: err})
+			return nil
+		}
+
+		if p == dir {
+			if !d.IsDir() { // a single file was given as root
+				w.add(lp)
+				return nil
+			}
+			w.visited[p] = true
+			return nil
+		}
+		level := depth + strings.Count(rel, string(filepath.Separator)) + 1
+
+		name := d.Name()
+		relRoot, _ := filepath.Rel(w.root, lp)
+		relRoot = filepath.ToSlash(relRoot)
+		if (!w.cfg.hidden && strings.HasPrefix(name, ".")) || matchAny(w.cfg.exclude, name, relRoot) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		switch {
+		case d.Type()&fs.ModeSymlink != 0:
+			if w.cfg.symlinks == followSymlinks {
+				return w.follow(p, lp, name, relRoot, level)
+			}
+		case d.IsDir():
+			if w.visited[p] {
+				return filepath.SkipDir
+			}
+			w.visited[p] = true
+			if w.cfg.maxDepth > 0 && level >= w.cfg.maxDepth {
+				return filepath.SkipDir
+			}
+		case d.Type().IsRegular():
+			if w.included(name, relRoot) {
+				w.add(lp)
+			}
+		}
+		// devices, sockets and pipes have no meaningful size
+		return nil
+	})
+}
+
+// errSymlinkLoop marks a link back to a directory the walk is already inside.
+var errSymlinkLoop = errors.New("symlink loop")
+
+// follow resolves the link at p and walks or records its target. Only
+// cancellation is returned; other problems are collected.
+func (w *walker) follow(p, lp, name, relRoot string, level int) error {
+	target, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		w.errs = append(w.errs, FileError{Path: lp, Err: err})
+		return nil
+	}
+	fi, err := os.Stat(target)
+	if err != nil {
+		w.errs = append(w.errs, FileError{Path: lp, Err: err})
+		return nil
+	}
+	switch {
+	case fi.IsDir():
+		if w.visited[target] {
+			if isAncestor(target, p) {
+				w.errs = append(w.errs, FileError{Path: lp, Err: fmt.Errorf("%w: points to %s", errSymlinkLoop, target)})
+			} else {
+				log.Printf("[walk] %s: %s already walked, skipping", lp, target)
+			}
+			return nil
+		}
+		if w.cfg.maxDepth > 0 && level >= w.cfg.maxDepth {
+			return nil
+		}
+		if err := w.walk(target, lp, level); err != nil {
+			if w.ctx.Err() != nil {
+				return err
+			}
+			w.errs = append(w.errs, FileError{Path: lp, Err: err})
+		}
+	case fi.Mode().IsRegular():
+		if w.included(name, relRoot) {
+			w.add(lp)
+		}
+	}
+	return nil
+}
+
+func (w *walker) add(lp string) {
+	w.files = append(w.files, lp)
+	w.cfg.progress.found()
+}
+
+func (w *walker) included(name, rel string) bool {
+	return len(w.cfg.include) == 0 || matchAny(w.cfg.include, name, rel)
+}
+
+func matchAny(patterns []string, name, rel string) bool {
+	for _, p := range patterns {
+		if ok, _ := filepath.Match(p, name); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(filepath.FromSlash(p), filepath.FromSlash(rel)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isAncestor reports whether dir contains path. Both must be resolved.
+func isAncestor(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// patternList is a flag that accepts comma-separated values and can be repeated.
+type patternList []string
+
+func (l *patternList) String() string { return strings.Join(*l, ",") }
+
+func (l *patternList) Set(s string) error {
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			*l = append(*l, p)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// walkTree builds a small tree used by the walk tests:
+//
+//	root/a.txt, root/b.bin, root/.hidden, root/sub/c.bin, root/sub/deep/d.txt,
+//	root/.git/config, root/build/e.o
+func walkTree(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	writeFiles(t, root, map[string]int{
+		"a.txt": 1, "b.bin": 2, ".hidden": 3, "sub/c.bin": 4, "sub/deep/d.txt": 5,
+		".git/config": 6, "build/e.o": 7,
+	})
+	return root
+}
+
+func relPaths(t *testing.T, root string, files []string) []string {
+	t.Helper()
+	out := make([]string, len(files))
+	for i, f := range files {
+		rel, err := filepath.Rel(root, f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out[i] = filepath.ToSlash(rel)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestWalkFiles(t *testing.T) {
+	root := walkTree(t)
+	tests := []struct {
+		name string
+		cfg  walkConfig
+		want []string
+	}{
+		{"defaults", walkConfig{}, []string{"a.txt", "b.bin", "build/e.o", "sub/c.bin", "sub/deep/d.txt"}},
+		{"hidden", walkConfig{hidden: true}, []string{".git/config", ".hidden", "a.txt", "b.bin", "build/e.o", "sub/c.bin", "sub/deep/d.txt"}},
+		{"include by name", walkConfig{include: []string{"*.bin"}}, []string{"b.bin", "sub/c.bin"}},
+		{"include by path", walkConfig{include: []string{"sub/*/*.txt"}}, []string{"sub/deep/d.txt"}},
+		{"exclude dir", walkConfig{exclude: []string{"build", "deep"}}, []string{"a.txt", "b.bin", "sub/c.bin"}},
+		{"exclude file", walkConfig{exclude: []string{"*.txt"}}, []string{"b.bin", "build/e.o", "sub/c.bin"}},
+		{"depth 1", walkConfig{maxDepth: 1}, []string{"a.txt", "b.bin"}},
+		{"depth 2", walkConfig{maxDepth: 2}, []string{"a.txt", "b.bin", "build/e.o", "sub/c.bin"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, errs, err := walkFiles(context.Background(), root, tt.cfg)
+			if err != nil || len(errs) > 0 {
+				t.Fatalf("err=%v errs=%v", err, errs)
+			}
+			if got := relPaths(t, root, files); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got=%v, want=%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWalkFilesInvalidConfig(t *testing.T) {
+	root := walkTree(t)
+	if _, _, err := walkFiles(context.Background(), root, walkConfig{include: []string{"[a-"}}); !errors.Is(err, filepath.ErrBadPattern) {
+		t.Fatalf("bad pattern: err=%v", err)
+	}
+	if _, _, err := walkFiles(context.Background(), root, walkConfig{symlinks: "maybe"}); err == nil {
+		t.Fatal("unknown symlink policy: want error")
+	}
+	if _, _, err := walkFiles(context.Background(), filepath.Join(root, "nope"), walkConfig{}); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("missing root: err=%v", err)
+	}
+}
+
+func TestWalkFilesSymlinks(t *testing.T) {
+	root := walkTree(t)
+	other := t.TempDir()
+	writeFiles(t, other, map[string]int{"x.bin": 8})
+	links := map[string]string{
+		"link-other": other,                          // directory outside the tree
+		"sub/loop":   root,                           // back to an ancestor
+		"link-sub":   filepath.Join(root, "sub"),     // directory walked anyway
+		"link-a":     filepath.Join(root, "a.txt"),   // file
+		"dangling":   filepath.Join(root, "missing"), // broken
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, name)); err != nil {
+			t.Skipf("symlinks not supported: %v", err)
+		}
+	}
+
+	files, errs, err := walkFiles(context.Background(), root, walkConfig{symlinks: skipSymlinks})
+	if err != nil || len(errs) > 0 {
+		t.Fatalf("skip: err=%v errs=%v", err, errs)
+	}
+	if got := relPaths(t, root, files); len(got) != 5 {
+		t.Fatalf("skip: got=%v, want the 5 regular files", got)
+	}
+
+	files, errs, err = walkFiles(context.Background(), root, walkConfig{symlinks: followSymlinks})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := relPaths(t, root, files)
+	// sub is walked once, either directly or through link-sub
+	want := map[string]bool{"a.txt": true, "b.bin": true, "build/e.o": true, "link-a": true, "link-other/x.bin": true}
+	var subFiles int
+	for _, f := range got {
+		switch {
+		case want[f]:
+			delete(want, f)
+		case f == "sub/c.bin" || f == "sub/deep/d.txt" || f == "link-sub/c.bin" || f == "link-sub/deep/d.txt":
+			subFiles++
+		default:
+			t.Fatalf("unexpected file %q in %v", f, got)
+		}
+	}
+	if len(want) > 0 || subFiles != 2 {
+		t.Fatalf("follow: got=%v, missing=%v", got, want)
+	}
+
+	var loops, broken int
+	for _, e := range errs {
+		switch {
+		case errors.Is(e, errSymlinkLoop):
+			loops++
+		case errors.Is(e, os.ErrNotExist):
+			broken++
+		}
+	}
+	if loops != 1 || broken != 1 || len(errs) != 2 {
+		t.Fatalf("follow: errs=%v, want one loop and one dangling link", errs)
+	}
+}
+
+func TestWalkFilesCanceled(t *testing.T) {
+	root := walkTree(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := walkFiles(ctx, root, walkConfig{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err=%v, want context.Canceled", err)
+	}
+}
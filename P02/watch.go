@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// deltaKind is how a file changed between two polls.
+type deltaKind string
+
+const (
+	deltaAdded    deltaKind = "added"
+	deltaRemoved  deltaKind = "removed"
+	deltaGrown    deltaKind = "grown"
+	deltaShrunk   deltaKind = "shrunk"
+	deltaModified deltaKind = "modified" // same size, newer mtime
+)
+
+// Delta is one file change seen by a poll.
+type Delta struct {
+	Kind    deltaKind
+	Path    string
+	OldSize int64
+	NewSize int64
+}
+
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// watcher keeps the last known size of every file under dir and, on each
+// poll, re-measures only files whose size or mtime moved.
+type watcher struct {
+	dir      string
+	walk     walkConfig
+	scan     scanConfig
+	debounce time.Duration    // files modified more recently than this wait for a later poll
+	now      func() time.Time // replaced in tests
+
+	mu    sync.Mutex // guards state and total
+	state map[string]fileState
+	total int64
+}
+
+func newWatcher(dir string, walk walkConfig, scan scanConfig, debounce time.Duration) *watcher {
+	return &watcher{
+		dir:      dir,
+		walk:     walk,
+		scan:     scan,
+		debounce: debounce,
+		now:      time.Now,
+		state:    map[string]fileState{},
+	}
+}
+
+// Total is the current size of all known files and their count.
+func (w *watcher) Total() (int64, int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.total, len(w.state)
+}
+
+// poll walks the tree once and returns what changed since the previous
+// poll, sorted by path. A file that cannot be stat'ed or measured keeps its
+// old state and is reported in errs, and so do known files below a directory
+// or link the walk could not read this time.
+func (w *watcher) poll(ctx context.Context) ([]Delta, []FileError, error) {
+	files, errs, err := walkFiles(ctx, w.dir, w.walk)
+	if err != nil {
+		return nil, nil, err
+	}
+	unreadable := errs // known files below these may still be there
+
+	w.mu.Lock()
+	known := make(map[string]fileState, len(w.state))
+	for p, st := range w.state {
+		known[p] = st
+	}
+	w.mu.Unlock()
+
+	var changed []string
+	stats := make(map[string]fileState, len(files))
+	seen := make(map[string]bool, len(files))
+	for _, p := range files {
+		seen[p] = true
+		fi, err := os.Stat(p)
+		if err != nil {
+			errs = append(errs, FileError{Path: p, Err: err})
+			continue
+		}
+		st := fileState{size: fi.Size(), modTime: fi.ModTime()}
+		if old, ok := known[p]; ok && old == st {
+			continue
+		}
+		if w.now().Sub(st.modTime) < w.debounce {
+			log.Printf("[watch] %s still changing, waiting", p)
+			continue
+		}
+		stats[p] = st
+		changed = append(changed, p)
+	}
+
+	res := scanFiles(ctx, changed, w.scan)
+	errs = append(errs, res.Errors...)
+	if res.Incomplete {
+		return nil, errs, ctx.Err()
+	}
+
+	var deltas []Delta
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, e := range res.Entries {
+		old, ok := w.state[e.Path]
+		d := Delta{Path: e.Path, OldSize: old.size, NewSize: e.Size}
+		switch {
+		case !ok:
+			d.Kind = deltaAdded
+		case e.Size > old.size:
+			d.Kind = deltaGrown
+		case e.Size < old.size:
+			d.Kind = deltaShrunk
+		default:
+			d.Kind = deltaModified
+		}
+		st := stats[e.Path]
+		st.size = e.Size // what was measured wins over the earlier stat
+		w.state[e.Path] = st
+		w.total += e.Size - old.size
+		deltas = append(deltas, d)
+	}
+	for p, old := range w.state {
+		if !seen[p] && !underAny(unreadable, p) {
+			delete(w.state, p)
+			w.total -= old.size
+			deltas = append(deltas, Delta{Kind: deltaRemoved, Path: p, OldSize: old.size})
+		}
+	}
+	sort.Slice(deltas, func(i, j int) bool { return deltas[i].Path < deltas[j].Path })
+	return deltas, errs, nil
+}
+
+// underAny reports whether p is one of the failed walk entries or lies below one.
+func underAny(failed []FileError, p string) bool {
+	for _, e := range failed {
+		if isAncestor(e.Path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// watch polls cfg.dir every interval until ctx ends, printing one line per
+// delta and the new total after every poll that changed something.
+func watch(ctx context.Context, cfg cliConfig, stdout, stderr io.Writer) int {
+	if cfg.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.timeout)
+		defer cancel()
+	}
+	w := newWatcher(cfg.dir, cfg.walk, cfg.scan, cfg.debounce)
+	if c := cfg.scan.cache; c != nil {
+		defer saveCache(c, stderr)
+	}
+	_, errs, err := w.poll(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return exitOK
+		}
+		fmt.Fprintln(stderr, "error:", err)
+		return exitUsage
+	}
+	for _, e := range errs {
+		fmt.Fprintln(stderr, "error:", e)
+	}
+	total, n := w.Total()
+	fmt.Fprintf(stdout, "watching %s: %s in %d files (every %s)\n", cfg.dir, humanBytes(total), n, cfg.interval)
+
+	t := time.NewTicker(cfg.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Printf("[watch] stopping: %v", ctx.Err())
+			return exitOK
+		case <-t.C:
+		}
+		deltas, errs, err := w.poll(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return exitOK
+			}
+			fmt.Fprintln(stderr, "error:", err) // e.g. dir removed; try again next tick
+			continue
+		}
+		for _, e := range errs {
+			fmt.Fprintln(stderr, "error:", e)
+		}
+		if len(deltas) == 0 {
+			continue
+		}
+		stamp := time.Now().Format(time.TimeOnly)
+		var diff int64
+		for _, d := range deltas {
+			diff += d.NewSize - d.OldSize
+			fmt.Fprintf(stdout, "%s %-8s %10s  %s\n", stamp, d.Kind, signedBytes(d.NewSize-d.OldSize), d.Path)
+		}
+		total, n := w.Total()
+		fmt.Fprintf(stdout, "%s total    %10s  %s in %d files\n", stamp, signedBytes(diff), humanBytes(total), n)
+	}
+}
+
+// signedBytes is humanBytes with an explicit sign, e.g. "+1.5 KiB".
+func signedBytes(n int64) string {
+	if n < 0 {
+		return "-" + humanBytes(-n)
+	}
+	return "+" + humanBytes(n)
+}
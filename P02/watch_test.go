@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWatcherPoll(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]int{"a": 10, "b": 20, "c": 30, "d": 40})
+	// backdate everything so debouncing never holds a file back
+	past := time.Now().Add(-time.Hour)
+	touch := func(name string, size int, at time.Time) {
+		p := filepath.Join(dir, name)
+		if size >= 0 {
+			if err := os.WriteFile(p, make([]byte, size), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := os.Chtimes(p, at, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, n := range []string{"a", "b", "c", "d"} {
+		touch(n, -1, past)
+	}
+
+	ctx := context.Background()
+	w := newWatcher(dir, walkConfig{}, scanConfig{workers: 2, strategy: statSize}, time.Second)
+	deltas, errs, err := w.poll(ctx)
+	if err != nil || len(errs) != 0 || len(deltas) != 4 || deltas[0].Kind != deltaAdded {
+		t.Fatalf("first poll: deltas=%+v errs=%v err=%v", deltas, errs, err)
+	}
+	if total, n := w.Total(); total != 100 || n != 4 {
+		t.Fatalf("total=%d files=%d, want 100 and 4", total, n)
+	}
+
+	deltas, _, _ = w.poll(ctx)
+	if len(deltas) != 0 {
+		t.Fatalf("unchanged tree reported %+v", deltas)
+	}
+
+	later := past.Add(time.Minute)
+	touch("a", 15, later) // grown
+	touch("b", 5, later)  // shrunk
+	touch("c", 30, later) // rewritten with the same size
+	os.Remove(filepath.Join(dir, "d"))
+	touch("e", 7, later)
+	deltas, _, err = w.poll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := func(n string) string { return filepath.Join(dir, n) }
+	want := []Delta{
+		{deltaGrown, p("a"), 10, 15},
+		{deltaShrunk, p("b"), 20, 5},
+		{deltaModified, p("c"), 30, 30},
+		{deltaRemoved, p("d"), 40, 0},
+		{deltaAdded, p("e"), 0, 7},
+	}
+	if !reflect.DeepEqual(deltas, want) {
+		t.Fatalf("deltas got=%+v, want=%+v", deltas, want)
+	}
+	if total, n := w.Total(); total != 57 || n != 4 {
+		t.Fatalf("total=%d files=%d, want 57 and 4", total, n)
+	}
+}
+
+func TestWatcherDebounce(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]int{"busy": 10})
+	mtime := time.Now()
+	if err := os.Chtimes(filepath.Join(dir, "busy"), mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	w := newWatcher(dir, walkConfig{}, scanConfig{workers: 1}, time.Minute)
+	w.now = func() time.Time { return mtime.Add(30 * time.Second) }
+	if deltas, _, _ := w.poll(context.Background()); len(deltas) != 0 {
+		t.Fatalf("file inside the debounce window was read: %+v", deltas)
+	}
+
+	w.now = func() time.Time { return mtime.Add(2 * time.Minute) }
+	if deltas, _, _ := w.poll(context.Background()); len(deltas) != 1 || deltas[0].Kind != deltaAdded {
+		t.Fatalf("settled file not picked up: %+v", deltas)
+	}
+}
+
+func TestWatcherUnreadableDir(t *testing.T) {
+	dir, outside := t.TempDir(), t.TempDir()
+	writeFiles(t, dir, map[string]int{"top": 5})
+	writeFiles(t, outside, map[string]int{"x": 10, "y": 20})
+	past := time.Now().Add(-time.Hour)
+	for _, p := range []string{filepath.Join(dir, "top"), filepath.Join(outside, "x"), filepath.Join(outside, "y")} {
+		if err := os.Chtimes(p, past, past); err != nil {
+			t.Fatal(err)
+		}
+	}
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(outside, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	ctx := context.Background()
+	w := newWatcher(dir, walkConfig{symlinks: followSymlinks}, scanConfig{workers: 2, strategy: statSize}, time.Second)
+	if deltas, _, err := w.poll(ctx); err != nil || len(deltas) != 3 {
+		t.Fatalf("first poll: deltas=%+v err=%v", deltas, err)
+	}
+
+	// the linked directory is unreadable for one poll: its files must not
+	// flap between removed and added
+	moved := outside + ".moved"
+	if err := os.Rename(outside, moved); err != nil {
+		t.Fatal(err)
+	}
+	deltas, errs, err := w.poll(ctx)
+	if err != nil || len(deltas) != 0 || len(errs) != 1 || errs[0].Path != link {
+		t.Fatalf("unreadable poll: deltas=%+v errs=%v err=%v", deltas, errs, err)
+	}
+	if total, n := w.Total(); total != 35 || n != 3 {
+		t.Fatalf("total=%d files=%d, want 35 and 3", total, n)
+	}
+
+	if err := os.Rename(moved, outside); err != nil {
+		t.Fatal(err)
+	}
+	if deltas, errs, err := w.poll(ctx); err != nil || len(deltas) != 0 || len(errs) != 0 {
+		t.Fatalf("readable again: deltas=%+v errs=%v err=%v", deltas, errs, err)
+	}
+}
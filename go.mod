@@ -3,7 +3,12 @@ module github.com/gloonch/scenarios
 go 1.22.2
 
 require (
-	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/cespare/xxhash/v2 v2.3.0
+	golang.org/x/crypto v0.31.0
+)
+
+require (
 	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
 	github.com/redis/go-redis/v9 v9.12.1 // indirect
+	golang.org/x/sys v0.28.0 // indirect
 )